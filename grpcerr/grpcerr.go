@@ -0,0 +1,72 @@
+package grpcerr
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Kana-v1-exchange/enviroment/postgres"
+	"github.com/Kana-v1-exchange/enviroment/redis"
+	"github.com/Kana-v1-exchange/enviroment/rmq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// known lists the errors the clients may see, with the codes they stand for.
+// Their texts are fixed, so they never carry internal details.
+var known = []struct {
+	err  error
+	code codes.Code
+}{
+	{context.Canceled, codes.Canceled},
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+	{postgres.ErrNotFound, codes.NotFound},
+	{redis.ErrNotFound, codes.NotFound},
+	{rmq.ErrNotFound, codes.NotFound},
+	{postgres.ErrDuplicateEmail, codes.AlreadyExists},
+	{postgres.ErrInvalidCredentials, codes.Unauthenticated},
+	{postgres.ErrInvalidAmount, codes.InvalidArgument},
+	{postgres.ErrInvalidPageToken, codes.InvalidArgument},
+	{postgres.ErrInsufficientFunds, codes.FailedPrecondition},
+	{postgres.ErrNoLiquidity, codes.FailedPrecondition},
+	{postgres.ErrConnectionLost, codes.Unavailable},
+	{redis.ErrConnectionLost, codes.Unavailable},
+	{rmq.ErrConnectionLost, codes.Unavailable},
+	{rmq.ErrClosed, codes.Unavailable},
+}
+
+// FromError converts an error of the postgres, redis or rmq packages into a gRPC status error
+// that the DashboardService handlers can return as is. The client only gets the text of the
+// package error it matches, never the wrapped details such as IDs, amounts or driver errors;
+// unknown errors become codes.Internal with a generic message.
+func FromError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	for _, k := range known {
+		if errors.Is(err, k.err) {
+			return status.Error(k.code, k.err.Error())
+		}
+	}
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+// Code returns the gRPC code the error stands for.
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+
+	for _, k := range known {
+		if errors.Is(err, k.err) {
+			return k.code
+		}
+	}
+
+	return codes.Internal
+}
@@ -0,0 +1,58 @@
+// Package errkind makes client errors of the postgres, redis and rmq packages match the package errors
+// they stand for, while keeping the client error in the chain.
+package errkind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Error keeps the client error and makes it match one of the package errors, its kind.
+type Error struct {
+	kind error
+	err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.kind, e.err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.kind
+}
+
+// Classify wraps a client error so that it matches the package error kindOf returns for it.
+// Errors kindOf returns nil for, errors that are already classified and errors of a cancelled
+// or expired context are returned as is: context.DeadlineExceeded is a net.Error too,
+// but an expired deadline says nothing about the connection.
+func Classify(err error, kindOf func(err error) error) error {
+	if err == nil {
+		return nil
+	}
+
+	var classified *Error
+	if errors.As(err, &classified) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	kind := kindOf(err)
+	if kind == nil {
+		return err
+	}
+
+	return &Error{kind: kind, err: err}
+}
+
+// IsNetwork reports whether err comes from the network connection of the client.
+func IsNetwork(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,67 @@
+package errkind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+var (
+	errLost    = errors.New("connection is lost")
+	errMissing = errors.New("not found")
+	errNoRow   = errors.New("no row")
+)
+
+func kindOf(err error) error {
+	switch {
+	case errors.Is(err, errNoRow):
+		return errMissing
+	case IsNetwork(err):
+		return errLost
+	}
+
+	return nil
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind error // nil if err must be returned as is
+	}{
+		{"no error", nil, nil},
+		{"unknown error", errors.New("syntax error"), nil},
+		{"package error", fmt.Errorf("query; err: %w", errNoRow), errMissing},
+		{"network error", &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, errLost},
+		{"unexpected eof", io.ErrUnexpectedEOF, errLost},
+		{"expired deadline", context.DeadlineExceeded, nil},
+		{"wrapped expired deadline", fmt.Errorf("query; err: %w", context.DeadlineExceeded), nil},
+		{"cancelled context", context.Canceled, nil},
+		{"already classified", &Error{kind: errMissing, err: io.EOF}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.err, kindOf)
+
+			if tt.wantKind == nil {
+				if got != tt.err {
+					t.Fatalf("Classify() = %v, want the error as is", got)
+				}
+
+				return
+			}
+
+			if !errors.Is(got, tt.wantKind) || !errors.Is(got, tt.err) {
+				t.Fatalf("Classify() = %v, want it to match %v and %v", got, tt.wantKind, tt.err)
+			}
+
+			if errors.Is(got, errLost) && tt.wantKind != errLost {
+				t.Fatalf("Classify() = %v matches %v", got, errLost)
+			}
+		})
+	}
+}
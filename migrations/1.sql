@@ -1 +0,0 @@
-CREATE DATABASE exchange;
\ No newline at end of file
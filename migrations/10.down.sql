@@ -0,0 +1,16 @@
+CREATE OR REPLACE FUNCTION give_start_money()
+    RETURNS trigger AS 
+    $$
+    BEGIN 
+        INSERT INTO users_money (user_id, currency, amount) 
+        VALUES(NEW.id, 'USD', 1000);
+        RETURN NEW;
+END;
+$$
+LANGUAGE 'plpgsql';
+
+DROP TABLE ledger_entries;
+
+DROP FUNCTION check_ledger_posting();
+
+DROP SEQUENCE ledger_postings_seq;
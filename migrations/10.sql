@@ -0,0 +1,77 @@
+CREATE SEQUENCE ledger_postings_seq;
+
+CREATE TABLE ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    posting_id BIGINT NOT NULL, -- entries of one posting sum up to zero for every currency
+    account VARCHAR(16) NOT NULL CHECK (account IN ('available', 'escrow', 'system')),
+    user_id INT REFERENCES users(id),
+    currency VARCHAR(10) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL, -- positive for credit, negative for debit
+    reference_type VARCHAR(32) NOT NULL,
+    reference_id BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((account = 'system') = (user_id IS NULL))
+);
+
+CREATE INDEX ledger_entries_posting ON ledger_entries (posting_id);
+CREATE INDEX ledger_entries_balance ON ledger_entries (user_id, currency, account);
+
+CREATE OR REPLACE FUNCTION check_ledger_posting()
+    RETURNS trigger AS
+    $$
+    BEGIN
+        IF EXISTS (
+            SELECT 1
+            FROM ledger_entries
+            WHERE posting_id = NEW.posting_id
+            GROUP BY currency
+            HAVING SUM(amount) <> 0
+        ) THEN
+            RAISE EXCEPTION 'ledger posting % is not balanced', NEW.posting_id;
+        END IF;
+        RETURN NULL;
+END;
+$$
+LANGUAGE 'plpgsql';
+
+CREATE CONSTRAINT TRIGGER ledger_posting_balanced
+AFTER INSERT
+ON ledger_entries
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE PROCEDURE check_ledger_posting();
+
+-- balances that existed before the ledger are booked as opening balances against the system account
+WITH opening AS (
+    SELECT nextval('ledger_postings_seq') AS posting_id, user_id, currency, 'available' AS account, amount
+    FROM users_money
+    WHERE amount <> 0
+    UNION ALL
+    SELECT nextval('ledger_postings_seq'), user_id, currency, 'escrow', SUM(amount)
+    FROM selling
+    WHERE side = 'ask'
+    GROUP BY user_id, currency
+)
+INSERT INTO ledger_entries (posting_id, account, user_id, currency, amount, reference_type)
+SELECT posting_id, account, user_id, currency, amount, 'opening_balance'
+FROM opening
+UNION ALL
+SELECT posting_id, 'system', NULL, currency, -amount, 'opening_balance'
+FROM opening;
+
+CREATE OR REPLACE FUNCTION give_start_money()
+    RETURNS trigger AS 
+    $$
+    DECLARE
+        posting BIGINT := nextval('ledger_postings_seq');
+    BEGIN 
+        INSERT INTO users_money (user_id, currency, amount) 
+        VALUES(NEW.id, 'USD', 1000);
+
+        INSERT INTO ledger_entries (posting_id, account, user_id, currency, amount, reference_type)
+        VALUES (posting, 'system', NULL, 'USD', -1000, 'start_money'),
+               (posting, 'available', NEW.id, 'USD', 1000, 'start_money');
+        RETURN NEW;
+END;
+$$
+LANGUAGE 'plpgsql';
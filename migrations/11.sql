@@ -0,0 +1,12 @@
+CREATE TABLE trades (
+    id BIGSERIAL PRIMARY KEY,
+    buyer_id INT REFERENCES users(id) NOT NULL,
+    seller_id INT REFERENCES users(id) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    price NUMERIC(20, 8) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX trades_buyer ON trades (buyer_id, created_at DESC, id DESC);
+CREATE INDEX trades_seller ON trades (seller_id, created_at DESC, id DESC);
@@ -0,0 +1,5 @@
+ALTER TABLE selling
+DROP CONSTRAINT selling_amount_positive;
+
+ALTER TABLE users_money
+DROP CONSTRAINT users_money_not_negative;
@@ -0,0 +1,7 @@
+-- NOT VALID keeps the migration working on databases that already have overdrawn rows;
+-- every new or updated row is checked anyway. Run VALIDATE CONSTRAINT once they are fixed.
+ALTER TABLE users_money
+ADD CONSTRAINT users_money_not_negative CHECK (amount >= 0) NOT VALID;
+
+ALTER TABLE selling
+ADD CONSTRAINT selling_amount_positive CHECK (amount > 0) NOT VALID;
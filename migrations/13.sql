@@ -0,0 +1,18 @@
+-- events written in the transaction that caused them; the outbox relay publishes them to the rmq
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    idempotency_key VARCHAR(64) NOT NULL UNIQUE,
+    event_type VARCHAR(255) NOT NULL,
+    routing_key VARCHAR(255) NOT NULL,
+    correlation_id VARCHAR(255) NOT NULL DEFAULT '',
+    content_type VARCHAR(64) NOT NULL,
+    payload BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    -- a relay publishes the messages it claimed outside of a transaction; others skip them until the claim expires
+    claimed_until TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_unpublished ON outbox (id) WHERE published_at IS NULL;
@@ -0,0 +1,11 @@
+-- Whether pass is an argon2id hash or a plaintext password of earlier versions. The application cannot hash
+-- here, so plaintext passwords are hashed on the next sign in and by PostgresHandler.HashPlaintextPasswords,
+-- which operators run once after upgrading.
+ALTER TABLE users
+ADD COLUMN pass_hashed BOOLEAN NOT NULL DEFAULT false;
+
+-- Earlier versions seeded an admin with the password 'admin'. Nobody can sign in with an empty hash.
+UPDATE users
+SET pass = '', pass_hashed = true
+WHERE email = 'admin'
+AND pass = 'admin';
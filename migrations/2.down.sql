@@ -0,0 +1,3 @@
+DROP TABLE users_money;
+DROP TABLE users;
+DROP TABLE currencies;
@@ -0,0 +1,2 @@
+DELETE FROM currencies
+WHERE currency IN ('EUR', 'JPY', 'AUD', 'CAD', 'CHF', 'USD', 'KRW');
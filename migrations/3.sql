@@ -1,6 +1,3 @@
-INSERT INTO users (email, pass)
-VALUES ('admin', 'admin');
-
 INSERT INTO currencies (currency, value) 
 VALUES ('EUR', 1.0130),
        ('JPY', 1.1972),
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS give_money_to_users ON users;
+
+DROP FUNCTION IF EXISTS give_start_money();
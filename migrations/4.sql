@@ -9,7 +9,10 @@ END;
 $$
 LANGUAGE 'plpgsql';
 
-CREATE OR REPLACE TRIGGER give_money_to_users
+-- CREATE OR REPLACE TRIGGER needs PostgreSQL 14
+DROP TRIGGER IF EXISTS give_money_to_users ON users;
+
+CREATE TRIGGER give_money_to_users
 AFTER INSERT
 ON users
 FOR EACH ROW
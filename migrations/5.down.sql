@@ -0,0 +1,2 @@
+ALTER TABLE users_money
+DROP CONSTRAINT unique_user_currency;
@@ -0,0 +1,12 @@
+ALTER TABLE selling
+ALTER COLUMN amount TYPE FLOAT,
+ALTER COLUMN price TYPE FLOAT;
+
+ALTER TABLE users_money
+ALTER COLUMN amount TYPE FLOAT;
+
+ALTER TABLE currencies
+ALTER COLUMN value TYPE FLOAT;
+
+ALTER TABLE currencies
+DROP COLUMN precision;
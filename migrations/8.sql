@@ -0,0 +1,26 @@
+ALTER TABLE currencies
+ADD COLUMN precision SMALLINT NOT NULL DEFAULT 2;
+
+UPDATE currencies
+SET precision = 0
+WHERE currency IN ('JPY', 'KRW');
+
+ALTER TABLE currencies
+ALTER COLUMN value TYPE NUMERIC(20, 8);
+
+ALTER TABLE users_money
+ALTER COLUMN amount TYPE NUMERIC(20, 8);
+
+ALTER TABLE selling
+ALTER COLUMN amount TYPE NUMERIC(20, 8),
+ALTER COLUMN price TYPE NUMERIC(20, 8);
+
+UPDATE users_money
+SET amount = trunc(users_money.amount, currencies.precision)
+FROM currencies
+WHERE currencies.currency = users_money.currency;
+
+UPDATE selling
+SET amount = trunc(selling.amount, currencies.precision)
+FROM currencies
+WHERE currencies.currency = selling.currency;
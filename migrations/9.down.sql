@@ -0,0 +1,28 @@
+DROP INDEX selling_book;
+
+DELETE FROM selling
+WHERE side = 'bid';
+
+UPDATE selling
+SET amount = merged.amount
+FROM (
+    SELECT MIN(id) AS id, SUM(amount) AS amount
+    FROM selling
+    GROUP BY user_id, currency, price
+) AS merged
+WHERE selling.id = merged.id;
+
+DELETE FROM selling
+WHERE id NOT IN (
+    SELECT MIN(id)
+    FROM selling
+    GROUP BY user_id, currency, price
+);
+
+ALTER TABLE selling
+DROP COLUMN side,
+DROP COLUMN created_at,
+DROP COLUMN escrow;
+
+ALTER TABLE selling
+ADD CONSTRAINT user_currency_price_constraint UNIQUE(user_id, currency, price);
@@ -0,0 +1,10 @@
+ALTER TABLE selling
+DROP CONSTRAINT user_currency_price_constraint;
+
+ALTER TABLE selling
+ADD COLUMN side VARCHAR(3) NOT NULL DEFAULT 'ask' CHECK (side IN ('ask', 'bid')),
+ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+-- QuoteCurrency a bid took from the buyer's balance and has not paid out yet
+ADD COLUMN escrow NUMERIC(20, 8) NOT NULL DEFAULT 0 CHECK (escrow >= 0);
+
+CREATE INDEX selling_book ON selling (currency, side, price, created_at, id);
@@ -0,0 +1,347 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Every migration is a pair of files: <version>.sql applies it and <version>.down.sql reverts it.
+//
+//go:embed *.sql
+var files embed.FS
+
+const downSuffix = ".down.sql"
+
+// migrationsLockID is the key of the advisory lock that serializes runners of different instances.
+const migrationsLockID = 7_393_201
+
+// legacyVersion is the last migration that existed before the runner. Databases migrated by hand
+// up to it have the users table but no schema_migrations table.
+const legacyVersion = 7
+
+type Migration struct {
+	Version int
+	Up      string
+	Down    string
+}
+
+// DB is implemented by *pgxpool.Pool and *pgx.Conn.
+type DB interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// All returns every embedded migration ordered by version.
+func All() ([]*Migration, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read embedded migrations; err: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		isDown := strings.HasSuffix(name, downSuffix)
+
+		versionStr := strings.TrimSuffix(strings.TrimSuffix(name, downSuffix), ".sql")
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("migration file %v is not named <version>.sql or <version>%v", name, downSuffix)
+		}
+
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read migration %v; err: %w", name, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version}
+			byVersion[version] = m
+		}
+
+		if isDown {
+			m.Down = string(content)
+		} else {
+			m.Up = string(content)
+		}
+	}
+
+	res := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %v has no up script", m.Version)
+		}
+
+		res = append(res, m)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+
+	return res, nil
+}
+
+// Up applies every pending migration in one transaction and returns the applied versions.
+// A database migrated by hand before the runner existed is baselined at legacyVersion first,
+// so only the migrations added since then are run.
+func Up(ctx context.Context, db DB) ([]int, error) {
+	applied := make([]int, 0)
+
+	err := inTx(ctx, db, func(tx pgx.Tx) error {
+		pending, err := pending(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range pending {
+			_, err = tx.Exec(ctx, m.Up)
+			if err != nil {
+				return fmt.Errorf("cannot apply migration %v; err: %w", m.Version, err)
+			}
+
+			_, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", m.Version)
+			if err != nil {
+				return fmt.Errorf("cannot record migration %v; err: %w", m.Version, err)
+			}
+
+			applied = append(applied, m.Version)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// Down reverts the last steps applied migrations in one transaction and returns the reverted versions.
+func Down(ctx context.Context, db DB, steps int) ([]int, error) {
+	reverted := make([]int, 0, steps)
+
+	err := inTx(ctx, db, func(tx pgx.Tx) error {
+		all, err := All()
+		if err != nil {
+			return err
+		}
+
+		appliedVersions, err := appliedVersions(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(all) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := all[i]
+			if !appliedVersions[m.Version] {
+				continue
+			}
+
+			if m.Down == "" {
+				return fmt.Errorf("migration %v cannot be reverted: it has no down script", m.Version)
+			}
+
+			_, err = tx.Exec(ctx, m.Down)
+			if err != nil {
+				return fmt.Errorf("cannot revert migration %v; err: %w", m.Version, err)
+			}
+
+			_, err = tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.Version)
+			if err != nil {
+				return fmt.Errorf("cannot remove migration %v from the schema_migrations table; err: %w", m.Version, err)
+			}
+
+			reverted = append(reverted, m.Version)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// Pending lists migrations that Up would apply without applying them.
+// It only reads: it neither takes the migrations lock nor creates the schema_migrations table.
+func Pending(ctx context.Context, db DB) ([]*Migration, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start migrations transaction; err: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "SET TRANSACTION READ ONLY")
+	if err != nil {
+		return nil, fmt.Errorf("cannot make the migrations transaction read only; err: %w", err)
+	}
+
+	return pending(ctx, tx)
+}
+
+// Baseline marks every migration up to and including version as applied without running it.
+// It is meant for databases whose schema was created by hand past legacyVersion; Up baselines
+// the ones created up to it on its own.
+func Baseline(ctx context.Context, db DB, version int) error {
+	return inTx(ctx, db, func(tx pgx.Tx) error {
+		all, err := All()
+		if err != nil {
+			return err
+		}
+
+		for _, m := range all {
+			if m.Version > version {
+				break
+			}
+
+			_, err = tx.Exec(
+				ctx,
+				`INSERT INTO schema_migrations (version)
+				 VALUES ($1)
+				 ON CONFLICT (version) DO NOTHING`,
+				m.Version,
+			)
+
+			if err != nil {
+				return fmt.Errorf("cannot record migration %v; err: %w", m.Version, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// inTx runs fn in a transaction that holds the migrations lock and has the schema_migrations table created.
+// A hand-migrated database is baselined at legacyVersion when the table is created.
+func inTx(ctx context.Context, db DB, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot start migrations transaction; err: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationsLockID)
+	if err != nil {
+		return fmt.Errorf("cannot take the migrations lock; err: %w", err)
+	}
+
+	hasTable, isLegacy, err := inspectSchema(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if !hasTable {
+		_, err = tx.Exec(
+			ctx,
+			`CREATE TABLE schema_migrations (
+				version INT PRIMARY KEY,
+				applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+			)`,
+		)
+
+		if err != nil {
+			return fmt.Errorf("cannot create the schema_migrations table; err: %w", err)
+		}
+	}
+
+	if isLegacy {
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO schema_migrations (version)
+			 SELECT generate_series(1, $1)`,
+			legacyVersion,
+		)
+
+		if err != nil {
+			return fmt.Errorf("cannot baseline the hand-migrated database; err: %w", err)
+		}
+	}
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func pending(ctx context.Context, tx pgx.Tx) ([]*Migration, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedVersions, err := appliedVersions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*Migration, 0)
+	for _, m := range all {
+		if !appliedVersions[m.Version] {
+			res = append(res, m)
+		}
+	}
+
+	return res, nil
+}
+
+// inspectSchema reports whether the schema_migrations table exists and, if it does not,
+// whether the database was migrated by hand up to legacyVersion.
+func inspectSchema(ctx context.Context, tx pgx.Tx) (hasTable, isLegacy bool, err error) {
+	err = tx.QueryRow(
+		ctx,
+		`SELECT to_regclass('schema_migrations') IS NOT NULL,
+		        to_regclass('users') IS NOT NULL`,
+	).Scan(&hasTable, &isLegacy)
+
+	if err != nil {
+		return false, false, fmt.Errorf("cannot inspect the database schema; err: %w", err)
+	}
+
+	return hasTable, !hasTable && isLegacy, nil
+}
+
+func appliedVersions(ctx context.Context, tx pgx.Tx) (map[int]bool, error) {
+	hasTable, isLegacy, err := inspectSchema(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasTable {
+		res := make(map[int]bool)
+		for version := 1; isLegacy && version <= legacyVersion; version++ {
+			res[version] = true
+		}
+
+		return res, nil
+	}
+
+	rows, err := tx.Query(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("cannot get applied migrations; err: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[int]bool)
+	for rows.Next() {
+		version := 0
+		err = rows.Scan(&version)
+		if err != nil {
+			return nil, fmt.Errorf("cannot scan migration version; err: %w", err)
+		}
+
+		res[version] = true
+	}
+
+	return res, rows.Err()
+}
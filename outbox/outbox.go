@@ -0,0 +1,185 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Kana-v1-exchange/enviroment/postgres"
+	"github.com/Kana-v1-exchange/enviroment/protos/events"
+	"github.com/Kana-v1-exchange/enviroment/retry"
+	"github.com/Kana-v1-exchange/enviroment/rmq"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+	defaultLease        = time.Minute
+
+	maxIdempotencyKeyLength = 64
+)
+
+// Enqueue stores the event in the outbox within tx, e.g. the transaction that recorded the trade,
+// so the event is published if and only if tx commits. idempotencyKey identifies the event, e.g.
+// "trade-42", and must be the same whenever the same event is enqueued again: it is sent as the
+// envelope and message ID, and an event whose key is already in the outbox is not stored twice.
+func Enqueue(ctx context.Context, ph postgres.PostgresHandler, tx postgres.Tx, idempotencyKey string, event proto.Message) error {
+	if idempotencyKey == "" || len(idempotencyKey) > maxIdempotencyKeyLength {
+		return fmt.Errorf("cannot enqueue %v: the idempotency key must have 1 to %v bytes", events.TypeOf(event), maxIdempotencyKeyLength)
+	}
+
+	encoded, err := rmq.EncodeEventWithID(ctx, idempotencyKey, event, events.ContentTypeProtobuf)
+	if err != nil {
+		return err
+	}
+
+	return ph.AddOutboxMessage(ctx, tx, &postgres.OutboxMessage{
+		IdempotencyKey: encoded.ID,
+		EventType:      encoded.Type,
+		RoutingKey:     encoded.RoutingKey,
+		CorrelationID:  encoded.CorrelationID,
+		ContentType:    encoded.ContentType,
+		Payload:        encoded.Body,
+	})
+}
+
+type RelayOptions struct {
+	BatchSize    int           // messages claimed at once; zero means 100
+	PollInterval time.Duration // wait after the outbox was found empty; zero means 1s
+	Lease        time.Duration // how long other relays leave claimed messages alone; zero means 1m
+	Logger       retry.Logger  // nil disables logging
+}
+
+// Relay publishes outbox messages and marks them published. It claims a batch in a short transaction,
+// publishes it without holding a transaction open and marks the published messages in another one.
+// A message is marked only after the broker confirmed it, so delivery is at least once: after a crash
+// between the two, or when publishing a batch outlasts the lease, the message is published again with
+// the same ID, which consumers use as the idempotency key. Several relays may run side by side,
+// so messages are not published in any particular order.
+// The rmq handler must be connected with publisher confirms enabled.
+type Relay struct {
+	ph   postgres.PostgresHandler
+	te   postgres.TransactionExecutor
+	rh   rmq.RmqHandler
+	opts RelayOptions
+}
+
+func NewRelay(ph postgres.PostgresHandler, te postgres.TransactionExecutor, rh rmq.RmqHandler, opts RelayOptions) *Relay {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+
+	if opts.Lease <= 0 {
+		opts.Lease = defaultLease
+	}
+
+	return &Relay{ph: ph, te: te, rh: rh, opts: opts}
+}
+
+// Run relays messages until ctx is done. Failed batches are logged and retried after PollInterval.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		relayed, err := r.RelayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logf("cannot relay outbox messages; err: %v", err)
+		}
+
+		// a full batch means there are probably more messages waiting
+		if err == nil && relayed == r.opts.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.opts.PollInterval):
+		}
+	}
+}
+
+// RelayBatch publishes one batch of unpublished messages and returns how many were published.
+// It stops at the first message that cannot be published and gives up the claim on the ones after it;
+// the messages published before it are still marked.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	var msgs []*postgres.OutboxMessage
+
+	err := r.te.WithTx(ctx, func(tx postgres.Tx) error {
+		var err error
+		msgs, err = r.ph.ClaimOutboxMessages(ctx, tx, r.opts.BatchSize, r.opts.Lease)
+		return err
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("cannot claim outbox messages; err: %w", err)
+	}
+
+	published := make([]uint64, 0, len(msgs))
+	var failed *postgres.OutboxMessage
+	var publishErr error
+
+	for _, msg := range msgs {
+		publishErr = r.rh.PublishConfirmed(ctx, &rmq.EncodedEvent{
+			ID:            msg.IdempotencyKey,
+			Type:          msg.EventType,
+			RoutingKey:    msg.RoutingKey,
+			CorrelationID: msg.CorrelationID,
+			ContentType:   msg.ContentType,
+			Timestamp:     msg.CreatedAt,
+			Body:          msg.Payload,
+		})
+
+		if publishErr != nil {
+			failed = msg
+			break
+		}
+
+		published = append(published, msg.ID)
+	}
+
+	unpublished := make([]uint64, 0)
+	for _, msg := range msgs[len(published):] {
+		if msg != failed {
+			unpublished = append(unpublished, msg.ID)
+		}
+	}
+
+	// the messages are already published, so marking them must not be cut short by ctx
+	markCtx := context.Background()
+
+	err = r.te.WithTx(markCtx, func(tx postgres.Tx) error {
+		err := r.ph.MarkOutboxPublished(markCtx, tx, published...)
+		if err != nil {
+			return err
+		}
+
+		if failed != nil {
+			err = r.ph.MarkOutboxFailed(markCtx, tx, failed.ID, publishErr.Error())
+			if err != nil {
+				return err
+			}
+		}
+
+		return r.ph.ReleaseOutboxMessages(markCtx, tx, unpublished...)
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("cannot mark %v published outbox messages; err: %w", len(published), err)
+	}
+
+	if publishErr != nil {
+		return len(published), fmt.Errorf("outbox relay stopped after %v messages; err: %w", len(published), publishErr)
+	}
+
+	return len(published), nil
+}
+
+func (r *Relay) logf(format string, v ...interface{}) {
+	if r.opts.Logger != nil {
+		r.opts.Logger.Printf(format, v...)
+	}
+}
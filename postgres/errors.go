@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Kana-v1-exchange/enviroment/internal/errkind"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/puddle"
+	"github.com/shopspring/decimal"
+)
+
+// Errors returned by the package. Check them with errors.Is; the driver error stays in the chain.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrInsufficientFunds  = errors.New("insufficient funds")
+	ErrDuplicateEmail     = errors.New("user with this email already exists")
+	ErrNoLiquidity        = errors.New("not enough money in the selling pool")
+	ErrConnectionLost     = errors.New("connection to the postgres database is lost")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidAmount      = errors.New("amount must be positive")
+	ErrInvalidPageToken   = errors.New("invalid page token")
+)
+
+// Names of the constraints that are reported as package errors.
+const (
+	usersEmailConstraint      = "users_email_key"
+	usersMoneyCheckConstraint = "users_money_not_negative"
+)
+
+// InsufficientPoolError is returned when the selling pool does not hold the requested amount.
+// It matches ErrNoLiquidity.
+type InsufficientPoolError struct {
+	Currency  string
+	Requested decimal.Decimal
+	Available decimal.Decimal
+}
+
+func (e *InsufficientPoolError) Error() string {
+	return fmt.Sprintf("%v: selling pool has %v %v, but %v was requested", ErrNoLiquidity, e.Available, e.Currency, e.Requested)
+}
+
+func (e *InsufficientPoolError) Is(target error) bool {
+	return target == ErrNoLiquidity
+}
+
+// InsufficientFundsError is returned when the user's balance is lower than the amount to take from it.
+// It matches ErrInsufficientFunds.
+type InsufficientFundsError struct {
+	UserID    uint64
+	Currency  string
+	Requested decimal.Decimal
+	Available decimal.Decimal
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("%v: user (id = %v) has %v %v, but %v is required", ErrInsufficientFunds, e.UserID, e.Available, e.Currency, e.Requested)
+}
+
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+// classify wraps a driver error so that it matches the package error it stands for.
+// Errors that do not stand for any are returned as is.
+func classify(err error) error {
+	return errkind.Classify(err, kindOf)
+}
+
+// kindOf returns the package error a driver error stands for, or nil.
+func kindOf(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505" && pgErr.ConstraintName == usersEmailConstraint: // unique_violation
+			return ErrDuplicateEmail
+		case pgErr.Code == "23514" && pgErr.ConstraintName == usersMoneyCheckConstraint: // check_violation
+			return ErrInsufficientFunds
+		}
+
+		return nil
+	}
+
+	if errkind.IsNetwork(err) || errors.Is(err, puddle.ErrClosedPool) || pgconn.SafeToRetry(err) {
+		return ErrConnectionLost
+	}
+
+	return nil
+}
+
+// isConfigError reports whether the server refused the connection because of its settings,
+// e.g. a wrong password or database name, which retrying does not fix.
+func isConfigError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	// invalid_authorization_specification, invalid_password and invalid_catalog_name
+	return pgErr.Code == "28000" || pgErr.Code == "28P01" || pgErr.Code == "3D000"
+}
+
+type classifiedRow struct {
+	row pgx.Row
+}
+
+func (r classifiedRow) Scan(dest ...interface{}) error {
+	return classify(r.row.Scan(dest...))
+}
+
+type classifiedRows struct {
+	pgx.Rows
+}
+
+func (r classifiedRows) Scan(dest ...interface{}) error {
+	return classify(r.Rows.Scan(dest...))
+}
+
+func (r classifiedRows) Err() error {
+	return classify(r.Rows.Err())
+}
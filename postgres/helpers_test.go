@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kana-v1-exchange/enviroment/migrations"
+	"github.com/Kana-v1-exchange/enviroment/retry"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// Tests that need a database are skipped unless POSTGRES_TEST_URL points to one.
+// `make test-postgres` starts a throwaway container and runs them against it.
+// Every test creates its own users and currencies, so tests may share the database and run in parallel.
+const testDatabaseEnv = "POSTGRES_TEST_URL"
+
+var (
+	testDBOnce sync.Once
+	testDB     *postgresClient
+	testDBErr  error
+
+	// names of test users and currencies must not clash with the ones of previous runs
+	testRandMu sync.Mutex
+	testRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+func testRandInt(n int) int {
+	testRandMu.Lock()
+	defer testRandMu.Unlock()
+
+	return testRand.Intn(n)
+}
+
+// newTestClient returns a client of the migrated test database.
+func newTestClient(t *testing.T) (*postgresClient, TransactionExecutor) {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseEnv)
+	if url == "" {
+		t.Skipf("%v is not set", testDatabaseEnv)
+	}
+
+	testDBOnce.Do(func() {
+		testDB, testDBErr = connectTestDB(url)
+	})
+
+	if testDBErr != nil {
+		t.Fatalf("cannot connect to the test database; err: %v", testDBErr)
+	}
+
+	return testDB, NewTransactionExecutor(testDB.pool)
+}
+
+func connectTestDB(url string) (*postgresClient, error) {
+	ctx := context.Background()
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, err
+	}
+
+	config.ConnConfig.PreferSimpleProtocol = true
+
+	opts := retry.DefaultOptions()
+	opts.MaxElapsedTime = 30 * time.Second
+
+	var pool *pgxpool.Pool
+	err = retry.Do(ctx, opts, "connecting to the test database", func(ctx context.Context) error {
+		p, err := pgxpool.ConnectConfig(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		err = p.Ping(ctx)
+		if err != nil {
+			p.Close()
+			return err
+		}
+
+		pool = p
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = migrations.Up(ctx, pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	params := DefaultPasswordParams()
+	params.Memory, params.Iterations = 1024, 1
+
+	return &postgresClient{pool: pool, passwordParams: params}, nil
+}
+
+// testCurrency adds a currency only the calling test uses.
+func testCurrency(t *testing.T, pc *postgresClient, precision int32) string {
+	t.Helper()
+
+	currency := fmt.Sprintf("T%09d", testRandInt(1_000_000_000))
+
+	err := pc.exec(
+		context.Background(),
+		`INSERT INTO currencies (currency, value, precision)
+		 VALUES ($1, 1, $2)`,
+		currency,
+		precision,
+	)
+
+	if err != nil {
+		t.Fatalf("cannot add currency %v; err: %v", currency, err)
+	}
+
+	return currency
+}
+
+// testUser adds a user that gets the start money, 1000 USD, like every new user.
+func testUser(t *testing.T, pc *postgresClient) uint64 {
+	t.Helper()
+
+	email := fmt.Sprintf("%v-%d@test", strings.ReplaceAll(t.Name(), "/", "-"), testRandInt(1_000_000_000))
+	userID := uint64(0)
+
+	err := pc.queryRow(
+		context.Background(),
+		`INSERT INTO users (email, pass, pass_hashed)
+		 VALUES ($1, '', true)
+		 RETURNING id`,
+		email,
+	).Scan(&userID)
+
+	if err != nil {
+		t.Fatalf("cannot add user %v; err: %v", email, err)
+	}
+
+	return userID
+}
+
+// setBalance sets the user's balance, booking the difference in the ledger.
+func setBalance(t *testing.T, pc *postgresClient, userID uint64, currency, amount string) {
+	t.Helper()
+
+	err := pc.UpdateCurrencyAmount(context.Background(), userID, currency, decimal.RequireFromString(amount))
+	if err != nil {
+		t.Fatalf("cannot set %v balance of the user (id = %v); err: %v", currency, userID, err)
+	}
+}
+
+// requireBalance fails the test if the user's balance is not amount.
+func requireBalance(t *testing.T, pc *postgresClient, userID uint64, currency, amount string) {
+	t.Helper()
+
+	balance, err := pc.GetUserMoney(context.Background(), userID, currency)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		t.Fatalf("cannot get %v balance of the user (id = %v); err: %v", currency, userID, err)
+	}
+
+	if !balance.Equal(decimal.RequireFromString(amount)) {
+		t.Fatalf("user (id = %v) has %v %v, want %v", userID, balance, currency, amount)
+	}
+}
+
+// requireReconciled fails the test if a balance of the users drifted from the ledger.
+func requireReconciled(t *testing.T, pc *postgresClient, userIDs ...uint64) {
+	t.Helper()
+
+	drifts, err := pc.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("cannot reconcile; err: %v", err)
+	}
+
+	for _, drift := range drifts {
+		for _, userID := range userIDs {
+			if drift.UserID == userID {
+				t.Errorf("%v %v balance of the user (id = %v) is %v, but the ledger says %v", drift.Account, drift.Currency, userID, drift.Stored, drift.Ledger)
+			}
+		}
+	}
+}
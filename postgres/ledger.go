@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// LedgerAccount is the kind of balance a ledger entry belongs to.
+type LedgerAccount string
+
+const (
+	LedgerAccountAvailable LedgerAccount = "available" // user's spendable money, mirrored by users_money
+	LedgerAccountEscrow    LedgerAccount = "escrow"    // user's money locked in orders, mirrored by selling
+	LedgerAccountSystem    LedgerAccount = "system"    // money that enters or leaves the exchange; it has no user
+)
+
+// Reference types tell which operation a ledger posting was made for.
+const (
+	LedgerRefOpeningBalance = "opening_balance"
+	LedgerRefStartMoney     = "start_money"
+	LedgerRefAdjustment     = "adjustment"
+	LedgerRefTrade          = "trade"
+	LedgerRefOrder          = "order"
+)
+
+// LedgerEntry credits (positive Amount) or debits (negative Amount) one account.
+// UserID is zero for the system account.
+type LedgerEntry struct {
+	Account  LedgerAccount
+	UserID   uint64
+	Currency string
+	Amount   decimal.Decimal
+}
+
+type LedgerReference struct {
+	Type string
+	ID   uint64
+}
+
+// LedgerDrift is a balance that does not match the sum of its ledger entries.
+type LedgerDrift struct {
+	UserID   uint64
+	Currency string
+	Account  LedgerAccount
+	Stored   decimal.Decimal // users_money for the available account, open asks and held bid money for the escrow one
+	Ledger   decimal.Decimal
+}
+
+// PostLedger records one balanced posting: for every currency the entries must sum up to zero.
+// The database checks the same when the transaction commits.
+func (pc *postgresClient) PostLedger(ctx context.Context, tx Tx, ref LedgerReference, entries ...*LedgerEntry) (uint64, error) {
+	return postLedger(ctx, tx, ref, entries...)
+}
+
+// Reconcile compares users_money and open orders with the ledger and returns every balance that drifted.
+func (pc *postgresClient) Reconcile(ctx context.Context) ([]*LedgerDrift, error) {
+	rows, err := pc.query(
+		ctx,
+		`WITH stored AS (
+			SELECT user_id, currency, 'available' AS account, amount
+			FROM users_money
+			UNION ALL
+			SELECT user_id, currency, 'escrow', SUM(amount)
+			FROM (
+				SELECT user_id, currency, amount
+				FROM selling
+				WHERE side = 'ask'
+				UNION ALL
+				SELECT user_id, $1, escrow
+				FROM selling
+				WHERE side = 'bid'
+			) held
+			GROUP BY user_id, currency
+		 ), ledger AS (
+			SELECT user_id, currency, account, SUM(amount) AS amount
+			FROM ledger_entries
+			WHERE account IN ('available', 'escrow')
+			GROUP BY user_id, currency, account
+		 )
+		 SELECT COALESCE(stored.user_id, ledger.user_id),
+			COALESCE(stored.currency, ledger.currency),
+			COALESCE(stored.account, ledger.account),
+			COALESCE(stored.amount, 0),
+			COALESCE(ledger.amount, 0)
+		 FROM stored
+			FULL OUTER JOIN ledger
+			ON ledger.user_id = stored.user_id
+			AND ledger.currency = stored.currency
+			AND ledger.account = stored.account
+		 WHERE COALESCE(stored.amount, 0) <> COALESCE(ledger.amount, 0)
+		 ORDER BY 1, 2, 3`,
+		QuoteCurrency,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot reconcile balances with the ledger; err: %w", err)
+	}
+	defer rows.Close()
+
+	drifts := make([]*LedgerDrift, 0)
+	for rows.Next() {
+		drift := &LedgerDrift{}
+		account := ""
+
+		err = rows.Scan(&drift.UserID, &drift.Currency, &account, &drift.Stored, &drift.Ledger)
+		if err != nil {
+			return nil, fmt.Errorf("cannot scan ledger drift; err: %w", err)
+		}
+
+		drift.Account = LedgerAccount(account)
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, rows.Err()
+}
+
+func postLedger(ctx context.Context, tx Tx, ref LedgerReference, entries ...*LedgerEntry) (uint64, error) {
+	sums := make(map[string]decimal.Decimal)
+	for _, entry := range entries {
+		sums[entry.Currency] = sums[entry.Currency].Add(entry.Amount)
+	}
+
+	for currency, sum := range sums {
+		if !sum.IsZero() {
+			return 0, fmt.Errorf("ledger posting for %v %v is not balanced: %v entries sum up to %v", ref.Type, ref.ID, currency, sum)
+		}
+	}
+
+	postingID := uint64(0)
+	err := tx.QueryRow(ctx, "SELECT nextval('ledger_postings_seq')").Scan(&postingID)
+	if err != nil {
+		return 0, fmt.Errorf("cannot start ledger posting; err: %w", err)
+	}
+
+	for _, entry := range entries {
+		err = tx.Exec(
+			ctx,
+			`INSERT INTO ledger_entries (posting_id, account, user_id, currency, amount, reference_type, reference_id)
+			 VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, NULLIF($7, 0))`,
+			postingID,
+			string(entry.Account),
+			entry.UserID,
+			entry.Currency,
+			entry.Amount,
+			ref.Type,
+			ref.ID,
+		)
+
+		if err != nil {
+			return 0, fmt.Errorf("cannot write ledger entry for %v %v; err: %w", ref.Type, ref.ID, err)
+		}
+	}
+
+	return postingID, nil
+}
+
+// moveMoney posts a transfer of amount from one account to another.
+func moveMoney(ctx context.Context, tx Tx, ref LedgerReference, currency string, amount decimal.Decimal, from, to *LedgerEntry) error {
+	from.Currency, from.Amount = currency, amount.Neg()
+	to.Currency, to.Amount = currency, amount
+
+	_, err := postLedger(ctx, tx, ref, from, to)
+	return err
+}
+
+func availableAccount(userID uint64) *LedgerEntry {
+	return &LedgerEntry{Account: LedgerAccountAvailable, UserID: userID}
+}
+
+func escrowAccount(userID uint64) *LedgerEntry {
+	return &LedgerEntry{Account: LedgerAccountEscrow, UserID: userID}
+}
+
+func systemAccount() *LedgerEntry {
+	return &LedgerEntry{Account: LedgerAccountSystem}
+}
@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/shopspring/decimal"
+)
+
+// LegacyPostgresHandler is the context-free version of PostgresHandler.
+//
+// Deprecated: use PostgresHandler and pass a request scoped context instead.
+type LegacyPostgresHandler interface {
+	GetCurrencies() (map[string]decimal.Decimal, error)
+	GetUsersNum() (int, error)
+	UpdateCurrency(currency string, value decimal.Decimal) error
+	GetCurrencyAmount(currency string) (decimal.Decimal, error)
+	GetCurrencyValue(currency string) (decimal.Decimal, error)
+	UpdateCurrencyAmount(userID uint64, currency string, value decimal.Decimal) error
+	AddUser(email, password string) error
+	GetUserData(email string) (uint64, string, error)
+	GetUserMoney(userID uint64, currency string) (decimal.Decimal, error)
+	FindSellers(tx LegacyTransactionExecutor, currency string, value decimal.Decimal, floorPrice, ceilPrice decimal.Decimal) ([]*SellingInfo, error)
+	AddMoneyToSellingPool(tx LegacyTransactionExecutor, currency string, userID uint64, amount, price decimal.Decimal) error
+	GetMoneyFromSellingPool(tx LegacyTransactionExecutor, currency string, userID uint64, amount, floorPrice, ceilPrice decimal.Decimal) (decimal.Decimal, error)
+	SendMoney(tx LegacyTransactionExecutor, senderID, receiverID uint64, currency string, value, price decimal.Decimal) (uint64, error)
+
+	Close()
+}
+
+// LegacyTransactionExecutor is the context-free version of TransactionExecutor.
+// It keeps a single current transaction between Begin and Commit/Rollback,
+// so it must not be shared between goroutines.
+//
+// Deprecated: use TransactionExecutor and pass a request scoped context instead.
+type LegacyTransactionExecutor interface {
+	Begin() error
+	Commit() error
+	Rollback() error
+	LockMoney() error
+	Exec(query string, args ...interface{}) error
+	Query(query string, args ...interface{}) (pgx.Rows, error)
+
+	unwrap() Tx
+}
+
+// NewLegacyHandlers wraps context-aware handlers so that not yet migrated callers
+// keep working. Every call runs with context.Background().
+//
+// Deprecated: use PostgresHandler and TransactionExecutor directly.
+func NewLegacyHandlers(ph PostgresHandler, te TransactionExecutor) (LegacyPostgresHandler, LegacyTransactionExecutor) {
+	return &legacyClient{ph}, &legacyTransExec{te: te}
+}
+
+type legacyClient struct {
+	ph PostgresHandler
+}
+
+func (lc *legacyClient) GetCurrencies() (map[string]decimal.Decimal, error) {
+	return lc.ph.GetCurrencies(context.Background())
+}
+
+func (lc *legacyClient) GetUsersNum() (int, error) {
+	return lc.ph.GetUsersNum(context.Background())
+}
+
+func (lc *legacyClient) UpdateCurrency(currency string, value decimal.Decimal) error {
+	return lc.ph.UpdateCurrency(context.Background(), currency, value)
+}
+
+func (lc *legacyClient) GetCurrencyAmount(currency string) (decimal.Decimal, error) {
+	return lc.ph.GetCurrencyAmount(context.Background(), currency)
+}
+
+func (lc *legacyClient) GetCurrencyValue(currency string) (decimal.Decimal, error) {
+	return lc.ph.GetCurrencyValue(context.Background(), currency)
+}
+
+func (lc *legacyClient) UpdateCurrencyAmount(userID uint64, currency string, value decimal.Decimal) error {
+	return lc.ph.UpdateCurrencyAmount(context.Background(), userID, currency, value)
+}
+
+func (lc *legacyClient) AddUser(email, password string) error {
+	return lc.ph.AddUser(context.Background(), email, password)
+}
+
+func (lc *legacyClient) GetUserData(email string) (uint64, string, error) {
+	return lc.ph.GetUserData(context.Background(), email)
+}
+
+func (lc *legacyClient) GetUserMoney(userID uint64, currency string) (decimal.Decimal, error) {
+	return lc.ph.GetUserMoney(context.Background(), userID, currency)
+}
+
+func (lc *legacyClient) FindSellers(tx LegacyTransactionExecutor, currency string, value decimal.Decimal, floorPrice, ceilPrice decimal.Decimal) ([]*SellingInfo, error) {
+	return lc.ph.FindSellers(context.Background(), tx.unwrap(), currency, value, floorPrice, ceilPrice)
+}
+
+// AddMoneyToSellingPool commits the transaction on success and rolls it back
+// on failure, as the context-free version always did.
+func (lc *legacyClient) AddMoneyToSellingPool(tx LegacyTransactionExecutor, currency string, userID uint64, amount, price decimal.Decimal) error {
+	err := lc.ph.AddMoneyToSellingPool(context.Background(), tx.unwrap(), currency, userID, amount, price)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (lc *legacyClient) GetMoneyFromSellingPool(tx LegacyTransactionExecutor, currency string, userID uint64, amount, floorPrice, ceilPrice decimal.Decimal) (decimal.Decimal, error) {
+	return lc.ph.GetMoneyFromSellingPool(context.Background(), tx.unwrap(), currency, userID, amount, floorPrice, ceilPrice)
+}
+
+func (lc *legacyClient) SendMoney(tx LegacyTransactionExecutor, senderID, receiverID uint64, currency string, value, price decimal.Decimal) (uint64, error) {
+	return lc.ph.SendMoney(context.Background(), tx.unwrap(), senderID, receiverID, currency, value, price)
+}
+
+func (lc *legacyClient) Close() {
+	lc.ph.Close()
+}
+
+type legacyTransExec struct {
+	te TransactionExecutor
+	tx Tx
+}
+
+var errLegacyTxNotBegun = errors.New("transaction is not begun")
+
+func (lte *legacyTransExec) Begin() error {
+	if lte.tx != nil {
+		return nil
+	}
+
+	tx, err := lte.te.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+
+	lte.tx = tx
+	return nil
+}
+
+func (lte *legacyTransExec) Commit() error {
+	if lte.tx == nil {
+		return nil
+	}
+
+	tx := lte.tx
+	lte.tx = nil
+	return tx.Commit(context.Background())
+}
+
+func (lte *legacyTransExec) Rollback() error {
+	if lte.tx == nil {
+		return nil
+	}
+
+	tx := lte.tx
+	lte.tx = nil
+	return tx.Rollback(context.Background())
+}
+
+func (lte *legacyTransExec) LockMoney() error {
+	err := lte.Begin()
+	if err != nil {
+		return err
+	}
+
+	return lte.tx.LockMoney(context.Background())
+}
+
+func (lte *legacyTransExec) Exec(query string, args ...interface{}) error {
+	if lte.tx == nil {
+		return errLegacyTxNotBegun
+	}
+
+	return lte.tx.Exec(context.Background(), query, args...)
+}
+
+func (lte *legacyTransExec) Query(query string, args ...interface{}) (pgx.Rows, error) {
+	if lte.tx == nil {
+		return nil, errLegacyTxNotBegun
+	}
+
+	return lte.tx.Query(context.Background(), query, args...)
+}
+
+// unwrap returns a Tx that follows the executor's current transaction.
+func (lte *legacyTransExec) unwrap() Tx {
+	return &legacyTx{lte}
+}
+
+type legacyTx struct {
+	lte *legacyTransExec
+}
+
+func (lt *legacyTx) Commit(ctx context.Context) error {
+	return lt.lte.Commit()
+}
+
+func (lt *legacyTx) Rollback(ctx context.Context) error {
+	return lt.lte.Rollback()
+}
+
+func (lt *legacyTx) LockMoney(ctx context.Context) error {
+	return lt.lte.LockMoney()
+}
+
+func (lt *legacyTx) Exec(ctx context.Context, query string, args ...interface{}) error {
+	if lt.lte.tx == nil {
+		return errLegacyTxNotBegun
+	}
+
+	return lt.lte.tx.Exec(ctx, query, args...)
+}
+
+func (lt *legacyTx) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	if lt.lte.tx == nil {
+		return nil, errLegacyTxNotBegun
+	}
+
+	return lt.lte.tx.Query(ctx, query, args...)
+}
+
+func (lt *legacyTx) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	if lt.lte.tx == nil {
+		return errRow{errLegacyTxNotBegun}
+	}
+
+	return lt.lte.tx.QueryRow(ctx, query, args...)
+}
+
+type errRow struct {
+	err error
+}
+
+func (er errRow) Scan(dest ...interface{}) error {
+	return er.err
+}
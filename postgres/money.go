@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/shopspring/decimal"
+)
+
+// querier is implemented by both the pool and Tx, so helpers can run inside or outside a transaction.
+type querier interface {
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+}
+
+// currencyPrecision returns the number of decimal places amounts of the currency are stored with.
+func currencyPrecision(ctx context.Context, q querier, currency string) (int32, error) {
+	precision := int32(0)
+
+	err := q.QueryRow(
+		ctx,
+		`SELECT precision
+		 FROM currencies
+		 WHERE currency = $1`,
+		currency,
+	).Scan(&precision)
+
+	if err != nil {
+		return 0, fmt.Errorf("cannot get precision of the currency %v; err: %w", currency, err)
+	}
+
+	return precision, nil
+}
+
+// roundToCurrency truncates amount to the currency precision. Truncating instead of rounding
+// half up guarantees that moving money never creates fractions that did not exist.
+func roundToCurrency(ctx context.Context, q querier, currency string, amount decimal.Decimal) (decimal.Decimal, error) {
+	precision, err := currencyPrecision(ctx, q, currency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return amount.Truncate(precision), nil
+}
+
+// debitUser takes amount from the user's balance. The balance row stays locked until the end of tx,
+// so concurrent debits are applied one after another and can never overdraw it.
+// A non-positive amount is rejected with ErrInvalidAmount: it would pass the balance check and credit the user.
+func debitUser(ctx context.Context, tx Tx, userID uint64, currency string, amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return fmt.Errorf("%w; cannot take %v %v from the user (id = %v)", ErrInvalidAmount, amount, currency, userID)
+	}
+
+	available := decimal.Zero
+
+	err := tx.QueryRow(
+		ctx,
+		`SELECT amount
+		 FROM users_money
+		 WHERE user_id = $1
+		 AND currency = $2
+		 FOR UPDATE`,
+		userID,
+		currency,
+	).Scan(&available)
+
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("cannot get %v balance of the user (id = %v); err: %w", currency, userID, err)
+	}
+
+	if available.LessThan(amount) {
+		return &InsufficientFundsError{UserID: userID, Currency: currency, Requested: amount, Available: available}
+	}
+
+	err = tx.Exec(
+		ctx,
+		`UPDATE users_money
+		 SET amount = amount - $1
+		 WHERE user_id = $2
+		 AND currency = $3`,
+		amount,
+		userID,
+		currency,
+	)
+
+	if err != nil {
+		return fmt.Errorf("cannot take %v %v from the user (id = %v); err: %w", amount, currency, userID, err)
+	}
+
+	return nil
+}
+
+func creditUser(ctx context.Context, tx Tx, userID uint64, currency string, amount decimal.Decimal) error {
+	err := tx.Exec(
+		ctx,
+		`INSERT INTO users_money (user_id, currency, amount)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (user_id, currency)
+		 DO UPDATE
+		 SET amount = users_money.amount + EXCLUDED.amount`,
+		userID,
+		currency,
+		amount,
+	)
+
+	if err != nil {
+		return fmt.Errorf("cannot give %v %v to the user (id = %v); err: %w", amount, currency, userID, err)
+	}
+
+	return nil
+}
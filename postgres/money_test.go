@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func sendMoney(pc *postgresClient, te TransactionExecutor, senderID, receiverID uint64, currency, value string) error {
+	return te.WithTx(context.Background(), func(tx Tx) error {
+		_, err := pc.SendMoney(context.Background(), tx, senderID, receiverID, currency, decimal.RequireFromString(value), decimal.NewFromInt(1))
+		return err
+	})
+}
+
+func TestSendMoneyRejectsNonPositiveAmounts(t *testing.T) {
+	pc, te := newTestClient(t)
+
+	for _, value := range []string{"0", "-10", "0.001"} {
+		value := value
+
+		t.Run(value, func(t *testing.T) {
+			t.Parallel()
+
+			currency := testCurrency(t, pc, 2)
+			sender, receiver := testUser(t, pc), testUser(t, pc)
+			setBalance(t, pc, sender, currency, "100")
+			setBalance(t, pc, receiver, currency, "100")
+
+			err := sendMoney(pc, te, sender, receiver, currency, value)
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Fatalf("SendMoney() err = %v, want %v", err, ErrInvalidAmount)
+			}
+
+			requireBalance(t, pc, sender, currency, "100")
+			requireBalance(t, pc, receiver, currency, "100")
+		})
+	}
+}
+
+func TestSendMoneyNeverOverdrawsUnderConcurrency(t *testing.T) {
+	pc, te := newTestClient(t)
+
+	tests := []struct {
+		name      string
+		balance   string
+		transfers int
+		value     string
+		wantSent  int
+	}{
+		{"more transfers than the balance covers", "100", 50, "3", 33},
+		{"balance covers every transfer", "100", 20, "5", 20},
+		{"balance covers none", "1", 20, "2", 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			currency := testCurrency(t, pc, 2)
+			sender, receiver := testUser(t, pc), testUser(t, pc)
+			setBalance(t, pc, sender, currency, tt.balance)
+
+			errs := make(chan error, tt.transfers)
+			wg := sync.WaitGroup{}
+
+			for i := 0; i < tt.transfers; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+					errs <- sendMoney(pc, te, sender, receiver, currency, tt.value)
+				}()
+			}
+
+			wg.Wait()
+			close(errs)
+
+			sent := 0
+			for err := range errs {
+				switch {
+				case err == nil:
+					sent++
+				case !errors.Is(err, ErrInsufficientFunds):
+					t.Errorf("SendMoney() err = %v", err)
+				}
+			}
+
+			if sent != tt.wantSent {
+				t.Errorf("%v transfers succeeded, want %v", sent, tt.wantSent)
+			}
+
+			value := decimal.RequireFromString(tt.value)
+			senderLeft := decimal.RequireFromString(tt.balance).Sub(value.Mul(decimal.NewFromInt(int64(tt.wantSent))))
+
+			requireBalance(t, pc, sender, currency, senderLeft.String())
+			requireBalance(t, pc, receiver, currency, value.Mul(decimal.NewFromInt(int64(tt.wantSent))).String())
+			requireReconciled(t, pc, sender, receiver)
+		})
+	}
+}
@@ -0,0 +1,549 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/shopspring/decimal"
+)
+
+type OrderSide string
+
+const (
+	OrderSideAsk OrderSide = "ask" // sells the currency; the amount is taken from the seller's balance when the order is placed
+	OrderSideBid OrderSide = "bid" // buys the currency; its price in QuoteCurrency is taken from the buyer's balance when the order is placed
+)
+
+// QuoteCurrency is the currency order prices are in and bids are paid with.
+const QuoteCurrency = "USD"
+
+// Order is a limit order. Asks and bids are both stored in the selling table.
+type Order struct {
+	ID        uint64
+	UserID    uint64
+	Currency  string
+	Side      OrderSide
+	Price     decimal.Decimal
+	Amount    decimal.Decimal // amount that is still open
+	Escrow    decimal.Decimal // QuoteCurrency still held by a bid; zero for asks
+	CreatedAt time.Time
+}
+
+// Fill is a part of an incoming (taker) order matched against a resting (maker) one.
+// It always executes at the maker's price.
+type Fill struct {
+	TradeID      uint64
+	MakerOrderID uint64
+	TakerOrderID uint64
+	BuyerID      uint64
+	SellerID     uint64
+	Currency     string
+	Price        decimal.Decimal
+	Amount       decimal.Decimal
+	Payment      decimal.Decimal // QuoteCurrency paid to the seller: Amount * Price truncated to its precision
+}
+
+// MatchOrder matches the incoming limit order against the opposite side of the book with
+// price-time priority: best price first, then the oldest order. Matched makers are reduced
+// or removed, the bought currency is credited to the buyer, the payment to the seller and
+// every fill is recorded as a trade. An unfilled remainder rests in the book; it is returned
+// with its ID, or nil if the order was filled completely.
+//
+// Both sides are funded up front: an ask takes its amount from the seller's balance and a bid
+// takes amount * price of QuoteCurrency from the buyer's one, so it fails with
+// *InsufficientFundsError if the user cannot pay. What a bid saves by buying below its price
+// is returned to the buyer when the bid is filled or cancelled.
+func (pc *postgresClient) MatchOrder(ctx context.Context, tx Tx, order *Order) ([]*Fill, *Order, error) {
+	if order.Side != OrderSideAsk && order.Side != OrderSideBid {
+		return nil, nil, fmt.Errorf("unknown order side %q", order.Side)
+	}
+
+	if order.Currency == QuoteCurrency {
+		return nil, nil, fmt.Errorf("%v cannot be traded for itself", QuoteCurrency)
+	}
+
+	if !order.Price.IsPositive() {
+		return nil, nil, fmt.Errorf("%w; order price is %v", ErrInvalidAmount, order.Price)
+	}
+
+	amount, err := roundToCurrency(ctx, tx, order.Currency, order.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !amount.IsPositive() {
+		return nil, nil, fmt.Errorf("%w; order amount is %v", ErrInvalidAmount, order.Amount)
+	}
+
+	quotePrecision, err := currencyPrecision(ctx, tx, QuoteCurrency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	escrow := decimal.Zero
+	if order.Side == OrderSideBid {
+		escrow = amount.Mul(order.Price).Truncate(quotePrecision)
+		if !escrow.IsPositive() {
+			return nil, nil, fmt.Errorf("%w; bid for %v %v at %v is worth less than the smallest %v amount", ErrInvalidAmount, amount, order.Currency, order.Price, QuoteCurrency)
+		}
+	}
+
+	takerID := uint64(0)
+	err = tx.QueryRow(ctx, "SELECT nextval(pg_get_serial_sequence('selling', 'id'))").Scan(&takerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot reserve an order id; err: %w", err)
+	}
+
+	fundCurrency, fundAmount := order.Currency, amount
+	if order.Side == OrderSideBid {
+		fundCurrency, fundAmount = QuoteCurrency, escrow
+	}
+
+	err = debitUser(ctx, tx, order.UserID, fundCurrency, fundAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = moveMoney(ctx, tx, LedgerReference{Type: LedgerRefOrder, ID: takerID}, fundCurrency, fundAmount, availableAccount(order.UserID), escrowAccount(order.UserID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	makers, err := pc.lockMatchingOrders(ctx, tx, order, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fills := make([]*Fill, 0)
+	remaining := amount
+
+	for _, maker := range makers {
+		if remaining.IsZero() {
+			break
+		}
+
+		filled := decimal.Min(remaining, maker.Amount)
+		remaining = remaining.Sub(filled)
+
+		fill := &Fill{
+			MakerOrderID: maker.ID,
+			TakerOrderID: takerID,
+			Currency:     order.Currency,
+			Price:        maker.Price,
+			Amount:       filled,
+			Payment:      filled.Mul(maker.Price).Truncate(quotePrecision),
+		}
+
+		makerSpent := decimal.Zero
+		if order.Side == OrderSideBid {
+			fill.BuyerID, fill.SellerID = order.UserID, maker.UserID
+			escrow = escrow.Sub(fill.Payment)
+		} else {
+			fill.BuyerID, fill.SellerID = maker.UserID, order.UserID
+			makerSpent = fill.Payment
+		}
+
+		err = reduceOrder(ctx, tx, maker, filled, makerSpent)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = settleFill(ctx, tx, fill)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fills = append(fills, fill)
+	}
+
+	if remaining.IsZero() {
+		err = releaseEscrow(ctx, tx, takerID, order.UserID, escrow)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return fills, nil, nil
+	}
+
+	resting := &Order{
+		ID:       takerID,
+		UserID:   order.UserID,
+		Currency: order.Currency,
+		Side:     order.Side,
+		Price:    order.Price,
+		Amount:   remaining,
+		Escrow:   escrow,
+	}
+
+	err = tx.QueryRow(
+		ctx,
+		`INSERT INTO selling (id, currency, user_id, amount, price, side, escrow)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING created_at`,
+		resting.ID,
+		resting.Currency,
+		resting.UserID,
+		resting.Amount,
+		resting.Price,
+		string(resting.Side),
+		resting.Escrow,
+	).Scan(&resting.CreatedAt)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot place the %v order of the user (id = %v); err: %w", resting.Side, resting.UserID, err)
+	}
+
+	return fills, resting, nil
+}
+
+// settleFill records the fill as a trade and moves the bought currency from the seller's escrow
+// to the buyer and the payment from the buyer's escrow to the seller.
+func settleFill(ctx context.Context, tx Tx, fill *Fill) error {
+	trade := &Trade{
+		BuyerID:  fill.BuyerID,
+		SellerID: fill.SellerID,
+		Currency: fill.Currency,
+		Price:    fill.Price,
+		Amount:   fill.Amount,
+	}
+
+	err := insertTrade(ctx, tx, trade)
+	if err != nil {
+		return err
+	}
+
+	fill.TradeID = trade.ID
+	ref := LedgerReference{Type: LedgerRefTrade, ID: trade.ID}
+
+	err = creditUser(ctx, tx, fill.BuyerID, fill.Currency, fill.Amount)
+	if err != nil {
+		return err
+	}
+
+	err = moveMoney(ctx, tx, ref, fill.Currency, fill.Amount, escrowAccount(fill.SellerID), availableAccount(fill.BuyerID))
+	if err != nil {
+		return err
+	}
+
+	if fill.Payment.IsZero() {
+		return nil
+	}
+
+	err = creditUser(ctx, tx, fill.SellerID, QuoteCurrency, fill.Payment)
+	if err != nil {
+		return err
+	}
+
+	return moveMoney(ctx, tx, ref, QuoteCurrency, fill.Payment, escrowAccount(fill.BuyerID), availableAccount(fill.SellerID))
+}
+
+// releaseEscrow returns QuoteCurrency that a bid no longer needs to the buyer's balance.
+func releaseEscrow(ctx context.Context, tx Tx, orderID, userID uint64, amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	err := creditUser(ctx, tx, userID, QuoteCurrency, amount)
+	if err != nil {
+		return err
+	}
+
+	return moveMoney(ctx, tx, LedgerReference{Type: LedgerRefOrder, ID: orderID}, QuoteCurrency, amount, escrowAccount(userID), availableAccount(userID))
+}
+
+// CancelOrder removes the user's resting order from the book. The open amount of an ask
+// or the QuoteCurrency held by a bid goes back to the user's balance.
+func (pc *postgresClient) CancelOrder(ctx context.Context, tx Tx, userID, orderID uint64) error {
+	order := &Order{}
+	side := ""
+
+	err := tx.QueryRow(
+		ctx,
+		`DELETE FROM selling
+		 WHERE id = $1
+		 AND user_id = $2
+		 RETURNING currency, side, amount, escrow`,
+		orderID,
+		userID,
+	).Scan(&order.Currency, &side, &order.Amount, &order.Escrow)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w; user (id = %v) has no order %v", ErrNotFound, userID, orderID)
+		}
+
+		return fmt.Errorf("cannot cancel order %v; err: %w", orderID, err)
+	}
+
+	if OrderSide(side) == OrderSideBid {
+		return releaseEscrow(ctx, tx, orderID, userID, order.Escrow)
+	}
+
+	err = creditUser(ctx, tx, userID, order.Currency, order.Amount)
+	if err != nil {
+		return err
+	}
+
+	return moveMoney(ctx, tx, LedgerReference{Type: LedgerRefOrder, ID: orderID}, order.Currency, order.Amount, escrowAccount(userID), availableAccount(userID))
+}
+
+// GetOrderBook returns up to depth best resting bids and asks of the currency, in priority order.
+// It fails with ErrInvalidAmount if depth is not positive.
+func (pc *postgresClient) GetOrderBook(ctx context.Context, currency string, depth int) ([]*Order, []*Order, error) {
+	if depth <= 0 {
+		return nil, nil, fmt.Errorf("%w; cannot get %v orders of the %v book", ErrInvalidAmount, depth, currency)
+	}
+
+	bids, err := pc.getBookSide(ctx, currency, OrderSideBid, depth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	asks, err := pc.getBookSide(ctx, currency, OrderSideAsk, depth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bids, asks, nil
+}
+
+func (pc *postgresClient) getBookSide(ctx context.Context, currency string, side OrderSide, depth int) ([]*Order, error) {
+	rows, err := pc.query(
+		ctx,
+		`SELECT id, user_id, currency, side, price, amount, escrow, created_at
+		 FROM selling
+		 WHERE currency = $1
+		 AND side = $2
+		 ORDER BY `+priorityOrder(side)+`
+		 LIMIT $3`,
+		currency,
+		string(side),
+		depth,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot get %v orders of the currency %v; err: %w", side, currency, err)
+	}
+	defer rows.Close()
+
+	return scanOrders(rows)
+}
+
+// lockMatchingOrders locks the resting orders the incoming one crosses with, in priority order,
+// until they cover amount. The user's own orders are never matched.
+func (pc *postgresClient) lockMatchingOrders(ctx context.Context, tx Tx, order *Order, amount decimal.Decimal) ([]*Order, error) {
+	q := &bookQuery{currency: order.Currency, side: OrderSideAsk, maxPrice: &order.Price, skipUserID: order.UserID}
+	if order.Side == OrderSideAsk {
+		q.side, q.minPrice, q.maxPrice = OrderSideBid, &order.Price, nil
+	}
+
+	makers, err := lockOrdersToCover(ctx, tx, q, amount)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find %v orders matching the price %v; err: %w", q.side, order.Price, err)
+	}
+
+	return makers, nil
+}
+
+// bookQuery selects resting orders of one side of the book.
+type bookQuery struct {
+	currency   string
+	side       OrderSide
+	minPrice   *decimal.Decimal // nil for no lower bound
+	maxPrice   *decimal.Decimal // nil for no upper bound
+	skipUserID uint64           // orders of this user are left out; zero for none
+	skipLocked bool             // skip orders locked by other transactions instead of waiting for them
+}
+
+// lockOrdersToCover locks orders in priority order until their open amounts add up to amount
+// or the book runs out, and returns the locked ones. Only the orders that are needed are locked:
+// candidates are picked with a running total of the amounts and then locked, and more are picked
+// only if some candidates were filled, reduced or, with skipLocked, are locked by someone else.
+func lockOrdersToCover(ctx context.Context, tx Tx, q *bookQuery, amount decimal.Decimal) ([]*Order, error) {
+	orders := make([]*Order, 0)
+	remaining := amount
+
+	var after *Order
+	for remaining.IsPositive() {
+		candidates, err := coveringCandidates(ctx, tx, q, after, remaining)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+
+		after = candidates[len(candidates)-1]
+
+		ids := make([]uint64, 0, len(candidates))
+		for _, candidate := range candidates {
+			ids = append(ids, candidate.ID)
+		}
+
+		locking := "FOR UPDATE"
+		if q.skipLocked {
+			locking = "FOR UPDATE SKIP LOCKED"
+		}
+
+		rows, err := tx.Query(
+			ctx,
+			`SELECT id, user_id, currency, side, price, amount, escrow, created_at
+			 FROM selling
+			 WHERE id = ANY($1)
+			 ORDER BY `+priorityOrder(q.side)+`
+			 `+locking,
+			ids,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("cannot lock %v orders %v; err: %w", q.side, ids, err)
+		}
+
+		locked, err := scanOrders(rows)
+		rows.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, order := range locked {
+			orders = append(orders, order)
+			remaining = remaining.Sub(order.Amount)
+		}
+	}
+
+	return orders, nil
+}
+
+// coveringCandidates returns the orders that come after the given one (or from the top of the book
+// if it is nil) and are needed to cover amount, judging by their current open amounts.
+// It only reads, so orders locked by other transactions are included.
+func coveringCandidates(ctx context.Context, tx Tx, q *bookQuery, after *Order, amount decimal.Decimal) ([]*Order, error) {
+	afterCond := "(price, created_at, id) > ($7, $8, $9)"
+	if q.side == OrderSideBid {
+		afterCond = "(price < $7 OR (price = $7 AND (created_at, id) > ($8, $9)))"
+	}
+
+	var afterPrice *decimal.Decimal
+	var afterCreatedAt *time.Time
+	afterID := uint64(0)
+
+	if after != nil {
+		afterPrice, afterCreatedAt, afterID = &after.Price, &after.CreatedAt, after.ID
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`SELECT id, price, created_at
+		 FROM (
+			SELECT id, price, created_at,
+				SUM(amount) OVER (ORDER BY `+priorityOrder(q.side)+`) - amount AS preceding
+			FROM selling
+			WHERE currency = $1
+			AND side = $2
+			AND ($3::NUMERIC IS NULL OR price >= $3)
+			AND ($4::NUMERIC IS NULL OR price <= $4)
+			AND user_id <> $5
+			AND ($7::NUMERIC IS NULL OR `+afterCond+`)
+		 ) book
+		 WHERE preceding < $6
+		 ORDER BY `+priorityOrder(q.side),
+		q.currency,
+		string(q.side),
+		q.minPrice,
+		q.maxPrice,
+		q.skipUserID,
+		amount,
+		afterPrice,
+		afterCreatedAt,
+		afterID,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot find %v orders of the currency %v to cover %v; err: %w", q.side, q.currency, amount, err)
+	}
+	defer rows.Close()
+
+	candidates := make([]*Order, 0)
+	for rows.Next() {
+		candidate := &Order{Currency: q.currency, Side: q.side}
+
+		err = rows.Scan(&candidate.ID, &candidate.Price, &candidate.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("cannot scan an order; err: %w", err)
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, rows.Err()
+}
+
+func priorityOrder(side OrderSide) string {
+	if side == OrderSideBid {
+		return "price DESC, created_at, id"
+	}
+
+	return "price, created_at, id"
+}
+
+func scanOrders(rows pgx.Rows) ([]*Order, error) {
+	orders := make([]*Order, 0)
+
+	for rows.Next() {
+		order := &Order{}
+		side := ""
+
+		err := rows.Scan(&order.ID, &order.UserID, &order.Currency, &side, &order.Price, &order.Amount, &order.Escrow, &order.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("cannot scan an order; err: %w", err)
+		}
+
+		order.Side = OrderSide(side)
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+// reduceOrder takes filled from the resting order, and spent from the QuoteCurrency a bid holds,
+// and removes the order from the book once nothing is left. What a removed bid still holds
+// is returned to the buyer.
+func reduceOrder(ctx context.Context, tx Tx, order *Order, filled, spent decimal.Decimal) error {
+	if order.Side == OrderSideBid && spent.GreaterThan(order.Escrow) {
+		return fmt.Errorf("bid %v holds %v %v, but %v is due", order.ID, order.Escrow, QuoteCurrency, spent)
+	}
+
+	if filled.Equal(order.Amount) {
+		err := tx.Exec(ctx, "DELETE FROM selling WHERE id = $1", order.ID)
+		if err != nil {
+			return fmt.Errorf("cannot remove filled order %v; err: %w", order.ID, err)
+		}
+
+		if order.Side != OrderSideBid {
+			return nil
+		}
+
+		return releaseEscrow(ctx, tx, order.ID, order.UserID, order.Escrow.Sub(spent))
+	}
+
+	err := tx.Exec(
+		ctx,
+		`UPDATE selling
+		 SET amount = amount - $1, escrow = escrow - $2
+		 WHERE id = $3`,
+		filled,
+		spent,
+		order.ID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("cannot reduce order %v by %v; err: %w", order.ID, filled, err)
+	}
+
+	return nil
+}
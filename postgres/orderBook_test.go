@@ -0,0 +1,306 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+type testOrder struct {
+	user   int // index of the test user
+	side   OrderSide
+	price  string
+	amount string
+}
+
+func (o testOrder) toOrder(userIDs []uint64, currency string) *Order {
+	return &Order{
+		UserID:   userIDs[o.user],
+		Currency: currency,
+		Side:     o.side,
+		Price:    decimal.RequireFromString(o.price),
+		Amount:   decimal.RequireFromString(o.amount),
+	}
+}
+
+// placeOrder matches the order in its own transaction.
+func placeOrder(t *testing.T, pc *postgresClient, te TransactionExecutor, order *Order) ([]*Fill, *Order, error) {
+	t.Helper()
+
+	var fills []*Fill
+	var resting *Order
+
+	err := te.WithTx(context.Background(), func(tx Tx) error {
+		var err error
+		fills, resting, err = pc.MatchOrder(context.Background(), tx, order)
+		return err
+	})
+
+	return fills, resting, err
+}
+
+func TestMatchOrder(t *testing.T) {
+	pc, te := newTestClient(t)
+
+	tests := []struct {
+		name         string
+		makers       []testOrder
+		taker        testOrder
+		wantFills    []string     // "<amount>@<price> for <payment>"
+		wantResting  string       // "<amount> holding <escrow>"; empty if the taker is filled
+		wantBalances [3][2]string // USD and the traded currency of every user
+	}{
+		{
+			name:         "bid buys the cheapest asks at their prices and gets the rest of its escrow back",
+			makers:       []testOrder{{0, OrderSideAsk, "2", "10"}, {1, OrderSideAsk, "1", "10"}},
+			taker:        testOrder{2, OrderSideBid, "3", "15"},
+			wantFills:    []string{"10@1 for 10", "5@2 for 10"},
+			wantBalances: [3][2]string{{"1010", "90"}, {"1010", "90"}, {"980", "115"}},
+		},
+		{
+			name:         "unfilled part of a bid rests with the escrow it still needs",
+			makers:       []testOrder{{0, OrderSideAsk, "2", "5"}},
+			taker:        testOrder{2, OrderSideBid, "3", "10"},
+			wantFills:    []string{"5@2 for 10"},
+			wantResting:  "5 holding 20",
+			wantBalances: [3][2]string{{"1010", "95"}, {"1000", "100"}, {"970", "105"}},
+		},
+		{
+			name:         "ask sells to the highest bids and is paid from their escrow",
+			makers:       []testOrder{{0, OrderSideBid, "2", "10"}, {1, OrderSideBid, "3", "10"}},
+			taker:        testOrder{2, OrderSideAsk, "1", "15"},
+			wantFills:    []string{"10@3 for 30", "5@2 for 10"},
+			wantBalances: [3][2]string{{"980", "105"}, {"970", "110"}, {"1040", "85"}},
+		},
+		{
+			name:         "orders that do not cross are not matched",
+			makers:       []testOrder{{0, OrderSideAsk, "2", "10"}},
+			taker:        testOrder{2, OrderSideBid, "1", "10"},
+			wantResting:  "10 holding 10",
+			wantBalances: [3][2]string{{"1000", "90"}, {"1000", "100"}, {"990", "100"}},
+		},
+		{
+			name:         "own orders are not matched",
+			makers:       []testOrder{{2, OrderSideAsk, "1", "10"}},
+			taker:        testOrder{2, OrderSideBid, "1", "10"},
+			wantResting:  "10 holding 10",
+			wantBalances: [3][2]string{{"1000", "100"}, {"1000", "100"}, {"990", "90"}},
+		},
+		{
+			name:         "payment is truncated to the USD precision",
+			makers:       []testOrder{{0, OrderSideAsk, "0.5", "0.33"}},
+			taker:        testOrder{2, OrderSideBid, "0.5", "0.33"},
+			wantFills:    []string{"0.33@0.5 for 0.16"},
+			wantBalances: [3][2]string{{"1000.16", "99.67"}, {"1000", "100"}, {"999.84", "100.33"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			currency := testCurrency(t, pc, 2)
+			userIDs := []uint64{testUser(t, pc), testUser(t, pc), testUser(t, pc)}
+
+			for _, userID := range userIDs {
+				setBalance(t, pc, userID, currency, "100")
+			}
+
+			for _, maker := range tt.makers {
+				_, _, err := placeOrder(t, pc, te, maker.toOrder(userIDs, currency))
+				if err != nil {
+					t.Fatalf("cannot place maker order; err: %v", err)
+				}
+			}
+
+			fills, resting, err := placeOrder(t, pc, te, tt.taker.toOrder(userIDs, currency))
+			if err != nil {
+				t.Fatalf("MatchOrder() err = %v", err)
+			}
+
+			gotFills := make([]string, 0, len(fills))
+			for _, fill := range fills {
+				gotFills = append(gotFills, fmt.Sprintf("%v@%v for %v", fill.Amount, fill.Price, fill.Payment))
+			}
+
+			if fmt.Sprint(gotFills) != fmt.Sprint(tt.wantFills) {
+				t.Errorf("fills = %v, want %v", gotFills, tt.wantFills)
+			}
+
+			gotResting := ""
+			if resting != nil {
+				gotResting = fmt.Sprintf("%v holding %v", resting.Amount, resting.Escrow)
+			}
+
+			if gotResting != tt.wantResting {
+				t.Errorf("resting = %q, want %q", gotResting, tt.wantResting)
+			}
+
+			for i, userID := range userIDs {
+				requireBalance(t, pc, userID, QuoteCurrency, tt.wantBalances[i][0])
+				requireBalance(t, pc, userID, currency, tt.wantBalances[i][1])
+			}
+
+			requireReconciled(t, pc, userIDs...)
+		})
+	}
+}
+
+func TestMatchOrderRejectsInvalidOrders(t *testing.T) {
+	pc, te := newTestClient(t)
+
+	tests := []struct {
+		name    string
+		order   testOrder
+		wantErr error
+	}{
+		{"zero price", testOrder{0, OrderSideBid, "0", "1"}, ErrInvalidAmount},
+		{"negative price", testOrder{0, OrderSideAsk, "-1", "1"}, ErrInvalidAmount},
+		{"negative amount", testOrder{0, OrderSideAsk, "1", "-1"}, ErrInvalidAmount},
+		{"amount below the currency precision", testOrder{0, OrderSideAsk, "1", "0.001"}, ErrInvalidAmount},
+		{"bid worth less than a cent", testOrder{0, OrderSideBid, "0.001", "1"}, ErrInvalidAmount},
+		{"bid the buyer cannot pay for", testOrder{0, OrderSideBid, "10", "101"}, ErrInsufficientFunds},
+		{"ask of more than the seller has", testOrder{0, OrderSideAsk, "1", "101"}, ErrInsufficientFunds},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			currency := testCurrency(t, pc, 2)
+			userIDs := []uint64{testUser(t, pc)}
+			setBalance(t, pc, userIDs[0], currency, "100")
+
+			_, _, err := placeOrder(t, pc, te, tt.order.toOrder(userIDs, currency))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MatchOrder() err = %v, want %v", err, tt.wantErr)
+			}
+
+			requireBalance(t, pc, userIDs[0], QuoteCurrency, "1000")
+			requireBalance(t, pc, userIDs[0], currency, "100")
+
+			bids, asks, err := pc.GetOrderBook(context.Background(), currency, 10)
+			if err != nil {
+				t.Fatalf("GetOrderBook() err = %v", err)
+			}
+
+			if len(bids)+len(asks) != 0 {
+				t.Errorf("book has %v bids and %v asks, want none", len(bids), len(asks))
+			}
+		})
+	}
+}
+
+func TestGetOrderBookRejectsNonPositiveDepth(t *testing.T) {
+	pc, _ := newTestClient(t)
+
+	for _, depth := range []int{0, -1} {
+		_, _, err := pc.GetOrderBook(context.Background(), QuoteCurrency, depth)
+		if !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("GetOrderBook(%v) err = %v, want %v", depth, err, ErrInvalidAmount)
+		}
+	}
+}
+
+func TestCancelOrderReleasesFunds(t *testing.T) {
+	pc, te := newTestClient(t)
+
+	tests := []struct {
+		name  string
+		order testOrder
+	}{
+		{"bid", testOrder{0, OrderSideBid, "2.5", "10"}},
+		{"ask", testOrder{0, OrderSideAsk, "2.5", "10"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			currency := testCurrency(t, pc, 2)
+			userIDs := []uint64{testUser(t, pc)}
+			setBalance(t, pc, userIDs[0], currency, "100")
+
+			_, resting, err := placeOrder(t, pc, te, tt.order.toOrder(userIDs, currency))
+			if err != nil {
+				t.Fatalf("MatchOrder() err = %v", err)
+			}
+
+			err = te.WithTx(context.Background(), func(tx Tx) error {
+				return pc.CancelOrder(context.Background(), tx, userIDs[0], resting.ID)
+			})
+
+			if err != nil {
+				t.Fatalf("CancelOrder() err = %v", err)
+			}
+
+			requireBalance(t, pc, userIDs[0], QuoteCurrency, "1000")
+			requireBalance(t, pc, userIDs[0], currency, "100")
+			requireReconciled(t, pc, userIDs...)
+		})
+	}
+}
+
+func TestMatchOrderLocksOnlyNeededOrders(t *testing.T) {
+	pc, te := newTestClient(t)
+	ctx := context.Background()
+
+	currency := testCurrency(t, pc, 2)
+	userIDs := []uint64{testUser(t, pc), testUser(t, pc), testUser(t, pc)}
+
+	askIDs := make([]uint64, 0, 2)
+	for i, price := range []string{"1", "2"} {
+		setBalance(t, pc, userIDs[i], currency, "100")
+
+		_, resting, err := placeOrder(t, pc, te, testOrder{i, OrderSideAsk, price, "10"}.toOrder(userIDs, currency))
+		if err != nil {
+			t.Fatalf("cannot place ask; err: %v", err)
+		}
+
+		askIDs = append(askIDs, resting.ID)
+	}
+
+	buyer, err := te.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() err = %v", err)
+	}
+	defer buyer.Rollback(ctx)
+
+	_, _, err = pc.MatchOrder(ctx, buyer, testOrder{2, OrderSideBid, "2", "5"}.toOrder(userIDs, currency))
+	if err != nil {
+		t.Fatalf("MatchOrder() err = %v", err)
+	}
+
+	other, err := te.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() err = %v", err)
+	}
+	defer other.Rollback(ctx)
+
+	err = other.Exec(ctx, "SELECT id FROM selling WHERE id = $1 FOR UPDATE NOWAIT", askIDs[0])
+	if err == nil {
+		t.Errorf("ask %v that the bid takes from is not locked", askIDs[0])
+	}
+
+	other.Rollback(ctx)
+
+	other, err = te.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() err = %v", err)
+	}
+	defer other.Rollback(ctx)
+
+	err = other.Exec(ctx, "SELECT id FROM selling WHERE id = $1 FOR UPDATE NOWAIT", askIDs[1])
+	if err != nil {
+		t.Errorf("ask %v that the bid does not need is locked; err: %v", askIDs[1], err)
+	}
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// OutboxMessage is an encoded event waiting in the outbox to be published.
+// IdempotencyKey is sent as the message ID, so consumers can drop redeliveries.
+type OutboxMessage struct {
+	ID             uint64
+	IdempotencyKey string
+	EventType      string
+	RoutingKey     string
+	CorrelationID  string
+	ContentType    string
+	Payload        []byte
+	CreatedAt      time.Time
+	Attempts       int
+}
+
+// AddOutboxMessage stores the message within tx, so it is published only if tx commits.
+// A message whose idempotency key is already in the outbox is ignored.
+func (pc *postgresClient) AddOutboxMessage(ctx context.Context, tx Tx, msg *OutboxMessage) error {
+	err := tx.Exec(
+		ctx,
+		`INSERT INTO outbox (idempotency_key, event_type, routing_key, correlation_id, content_type, payload)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (idempotency_key) DO NOTHING`,
+		msg.IdempotencyKey,
+		msg.EventType,
+		msg.RoutingKey,
+		msg.CorrelationID,
+		msg.ContentType,
+		msg.Payload,
+	)
+
+	if err != nil {
+		return fmt.Errorf("cannot add %v %v to the outbox; err: %w", msg.EventType, msg.IdempotencyKey, err)
+	}
+
+	return nil
+}
+
+// ClaimOutboxMessages claims up to limit oldest unpublished messages for lease and returns them by ID.
+// Messages claimed by another relay whose claim has not expired yet are skipped. The claim holds once tx
+// commits, so tx should be short and the messages published after it.
+func (pc *postgresClient) ClaimOutboxMessages(ctx context.Context, tx Tx, limit int, lease time.Duration) ([]*OutboxMessage, error) {
+	rows, err := tx.Query(
+		ctx,
+		`UPDATE outbox
+		 SET claimed_until = now() + $2 * interval '1 millisecond'
+		 WHERE id IN (
+		     SELECT id
+		     FROM outbox
+		     WHERE published_at IS NULL
+		     AND (claimed_until IS NULL OR claimed_until < now())
+		     ORDER BY id
+		     LIMIT $1
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING id, idempotency_key, event_type, routing_key, correlation_id, content_type, payload, created_at, attempts`,
+		limit,
+		lease.Milliseconds(),
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot get unpublished outbox messages; err: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]*OutboxMessage, 0)
+	for rows.Next() {
+		msg := &OutboxMessage{}
+
+		err = rows.Scan(&msg.ID, &msg.IdempotencyKey, &msg.EventType, &msg.RoutingKey, &msg.CorrelationID, &msg.ContentType, &msg.Payload, &msg.CreatedAt, &msg.Attempts)
+		if err != nil {
+			return nil, fmt.Errorf("cannot scan outbox message; err: %w", err)
+		}
+
+		res = append(res, msg)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("cannot get unpublished outbox messages; err: %w", rows.Err())
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+
+	return res, nil
+}
+
+func (pc *postgresClient) MarkOutboxPublished(ctx context.Context, tx Tx, ids ...uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := tx.Exec(
+		ctx,
+		`UPDATE outbox
+		 SET published_at = now(), claimed_until = NULL, attempts = attempts + 1, last_error = NULL
+		 WHERE id = ANY($1)`,
+		ids,
+	)
+
+	if err != nil {
+		return fmt.Errorf("cannot mark outbox messages %v as published; err: %w", ids, err)
+	}
+
+	return nil
+}
+
+func (pc *postgresClient) MarkOutboxFailed(ctx context.Context, tx Tx, id uint64, cause string) error {
+	err := tx.Exec(
+		ctx,
+		`UPDATE outbox
+		 SET claimed_until = NULL, attempts = attempts + 1, last_error = $1
+		 WHERE id = $2`,
+		cause,
+		id,
+	)
+
+	if err != nil {
+		return fmt.Errorf("cannot record the failed publishing of outbox message %v; err: %w", id, err)
+	}
+
+	return nil
+}
+
+// ReleaseOutboxMessages gives up the claim on messages that were not published, so any relay may take them.
+func (pc *postgresClient) ReleaseOutboxMessages(ctx context.Context, tx Tx, ids ...uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := tx.Exec(
+		ctx,
+		`UPDATE outbox
+		 SET claimed_until = NULL
+		 WHERE id = ANY($1)
+		 AND published_at IS NULL`,
+		ids,
+	)
+
+	if err != nil {
+		return fmt.Errorf("cannot release outbox messages %v; err: %w", ids, err)
+	}
+
+	return nil
+}
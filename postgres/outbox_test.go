@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// claimedKeys claims every unclaimed message and returns the keys among them that start with prefix.
+func claimedKeys(t *testing.T, pc *postgresClient, te TransactionExecutor, prefix string, lease time.Duration) map[string]uint64 {
+	t.Helper()
+
+	var msgs []*OutboxMessage
+	err := te.WithTx(context.Background(), func(tx Tx) error {
+		var err error
+		msgs, err = pc.ClaimOutboxMessages(context.Background(), tx, 1_000_000, lease)
+		return err
+	})
+
+	if err != nil {
+		t.Fatalf("ClaimOutboxMessages() err = %v", err)
+	}
+
+	keys := make(map[string]uint64)
+	for _, msg := range msgs {
+		if strings.HasPrefix(msg.IdempotencyKey, prefix) {
+			keys[msg.IdempotencyKey] = msg.ID
+		}
+	}
+
+	return keys
+}
+
+func TestClaimOutboxMessages(t *testing.T) {
+	pc, te := newTestClient(t)
+	ctx := context.Background()
+
+	prefix := fmt.Sprintf("claim-%09d-", testRandInt(1_000_000_000))
+
+	err := te.WithTx(ctx, func(tx Tx) error {
+		for i := 0; i < 3; i++ {
+			err := pc.AddOutboxMessage(ctx, tx, &OutboxMessage{
+				IdempotencyKey: fmt.Sprintf("%v%v", prefix, i),
+				EventType:      "test",
+				RoutingKey:     "test",
+				ContentType:    "test",
+				Payload:        []byte{},
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("AddOutboxMessage() err = %v", err)
+	}
+
+	first := claimedKeys(t, pc, te, prefix, time.Hour)
+	if len(first) != 3 {
+		t.Fatalf("first claim got %v of the messages, want 3", len(first))
+	}
+
+	if second := claimedKeys(t, pc, te, prefix, time.Hour); len(second) != 0 {
+		t.Fatalf("second claim got %v claimed messages, want none", len(second))
+	}
+
+	err = te.WithTx(ctx, func(tx Tx) error {
+		err := pc.MarkOutboxPublished(ctx, tx, first[prefix+"0"])
+		if err != nil {
+			return err
+		}
+
+		return pc.ReleaseOutboxMessages(ctx, tx, first[prefix+"0"], first[prefix+"1"])
+	})
+
+	if err != nil {
+		t.Fatalf("cannot mark the messages; err: %v", err)
+	}
+
+	third := claimedKeys(t, pc, te, prefix, time.Hour)
+	if len(third) != 1 || third[prefix+"1"] == 0 {
+		t.Errorf("third claim got %v, want only the released message %v1", third, prefix)
+	}
+}
@@ -4,16 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/Kana-v1-exchange/enviroment/migrations"
+	"github.com/Kana-v1-exchange/enviroment/retry"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/shopspring/decimal"
 )
 
+// SellingInfo is one step of a fill plan: Amount of the currency to buy from the ask OrderID.
 type SellingInfo struct {
+	OrderID  uint64
 	UserID   uint64
-	Amount   float64
+	Amount   decimal.Decimal
 	Currency string
-	Price    float64
+	Price    decimal.Decimal
 }
 
 type PostgreSettings struct {
@@ -22,74 +28,185 @@ type PostgreSettings struct {
 	Host     string
 	Port     string
 	DbName   string
+
+	MaxConns        int32         // pool size; pgxpool default is used when zero
+	MinConns        int32         // number of connections kept open even when idle
+	MaxConnIdleTime time.Duration // idle connections older than that are closed
+	MaxConnLifetime time.Duration // connections older than that are closed and replaced
+
+	SkipMigrations bool // do not apply pending migrations on Connect
+
+	PasswordParams *PasswordParams // argon2id parameters of new password hashes; DefaultPasswordParams when nil
 }
 
+// PostgresHandler methods that take a Tx run inside it and never commit or roll it back;
+// that is up to the caller that began the transaction.
 type PostgresHandler interface {
-	GetCurrencies() (map[string]float64, error)
-	GetUsersNum() (int, error)
-	UpdateCurrency(currency string, value float64) error
-	GetCurrencyAmount(currency string) (float64, error)
-	GetCurrencyValue(currency string) (float64, error)
-	UpdateCurrencyAmount(userID uint64, currency string, value float64) error
-	AddUser(email, password string) error
-	GetUserData(email string) (uint64, string, error)
-	GetUserMoney(userID uint64, currency string) (float64, error)
-	FindSellers(tx TransactionExecutor, currency string, value float64, floorPrice, ceilPrice float64) ([]*SellingInfo, error)
-	AddMoneyToSellingPool(tx TransactionExecutor, currency string, userID uint64, amount, price float64) error
-	GetMoneyFromSellingPool(tx TransactionExecutor, currency string, userID uint64, amount, floorPrice, ceilPrice float64) error
-	SendMoney(tx TransactionExecutor, senderID, receiverID uint64, currency string, value float64) error
+	GetCurrencies(ctx context.Context) (map[string]decimal.Decimal, error)
+	GetUsersNum(ctx context.Context) (int, error)
+	UpdateCurrency(ctx context.Context, currency string, value decimal.Decimal) error
+	GetCurrencyAmount(ctx context.Context, currency string) (decimal.Decimal, error)
+	GetCurrencyValue(ctx context.Context, currency string) (decimal.Decimal, error)
+	GetCurrencyPrecision(ctx context.Context, currency string) (int32, error)
+	UpdateCurrencyAmount(ctx context.Context, userID uint64, currency string, value decimal.Decimal) error
+	AddUser(ctx context.Context, email, password string) error
+	VerifyCredentials(ctx context.Context, email, password string) (uint64, error)
+	HashPlaintextPasswords(ctx context.Context, batchSize int) (int, error)
+	GetUserData(ctx context.Context, email string) (uint64, string, error)
+	GetUserMoney(ctx context.Context, userID uint64, currency string) (decimal.Decimal, error)
+	FindSellers(ctx context.Context, tx Tx, currency string, value decimal.Decimal, floorPrice, ceilPrice decimal.Decimal) ([]*SellingInfo, error)
+	AddMoneyToSellingPool(ctx context.Context, tx Tx, currency string, userID uint64, amount, price decimal.Decimal) error
+	GetMoneyFromSellingPool(ctx context.Context, tx Tx, currency string, userID uint64, amount, floorPrice, ceilPrice decimal.Decimal) (decimal.Decimal, error)
+	SendMoney(ctx context.Context, tx Tx, senderID, receiverID uint64, currency string, value, price decimal.Decimal) (uint64, error)
+	GetUserTrades(ctx context.Context, userID uint64, filter TradeFilter) (*TradesPage, error)
+
+	MatchOrder(ctx context.Context, tx Tx, order *Order) ([]*Fill, *Order, error)
+	CancelOrder(ctx context.Context, tx Tx, userID, orderID uint64) error
+	GetOrderBook(ctx context.Context, currency string, depth int) ([]*Order, []*Order, error)
+
+	PostLedger(ctx context.Context, tx Tx, ref LedgerReference, entries ...*LedgerEntry) (uint64, error)
+	Reconcile(ctx context.Context) ([]*LedgerDrift, error)
+
+	AddOutboxMessage(ctx context.Context, tx Tx, msg *OutboxMessage) error
+	ClaimOutboxMessages(ctx context.Context, tx Tx, limit int, lease time.Duration) ([]*OutboxMessage, error)
+	MarkOutboxPublished(ctx context.Context, tx Tx, ids ...uint64) error
+	MarkOutboxFailed(ctx context.Context, tx Tx, id uint64, cause string) error
+	ReleaseOutboxMessages(ctx context.Context, tx Tx, ids ...uint64) error
+
+	Close()
 }
 
 type postgresClient struct {
-	connection *pgxpool.Conn
+	pool           *pgxpool.Pool
+	passwordParams PasswordParams
+	logger         retry.Logger // nil disables logging
 }
 
-func (ps *PostgreSettings) Connect() (PostgresHandler, TransactionExecutor) {
-	connStr := fmt.Sprintf("postgresql://%s:%s@%s/%s?pool_min_conns=2&prefer_simple_protocol=true", ps.User, ps.Password, ps.Host, ps.DbName)
-
-	pool, err := pgxpool.Connect(context.Background(), connStr)
+// MustConnect is Connect with the default retry options that panics when the database stays unreachable.
+func (ps *PostgreSettings) MustConnect() (PostgresHandler, TransactionExecutor) {
+	ph, te, err := ps.Connect(context.Background(), retry.DefaultOptions())
 	if err != nil {
-		panic(fmt.Errorf("cannot connect to the postgres database; err: %v", err))
+		panic(err)
 	}
 
-	pgConn, err := pool.Acquire(context.Background())
+	return ph, te
+}
+
+// Connect opens the pool and migrates the database. Connecting is retried with backoff
+// as opts tell, so the database may still be starting when Connect is called.
+func (ps *PostgreSettings) Connect(ctx context.Context, opts retry.Options) (PostgresHandler, TransactionExecutor, error) {
+	connStr := fmt.Sprintf("postgresql://%s:%s@%s/%s?prefer_simple_protocol=true", ps.User, ps.Password, ps.Host, ps.DbName)
+
+	config, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
-		panic("cannot get connection from the pool")
+		return nil, nil, fmt.Errorf("cannot parse the postgres connection string; err: %w", err)
 	}
 
-	err = pgConn.Ping(context.Background())
+	ps.applyPoolSettings(config)
+
+	var pool *pgxpool.Pool
+
+	err = retry.Do(ctx, opts, "connecting to postgres", func(ctx context.Context) error {
+		p, err := pgxpool.ConnectConfig(ctx, config)
+		if err != nil {
+			return fmt.Errorf("cannot connect to the postgres database; err: %w", classify(err))
+		}
+
+		err = p.Ping(ctx)
+		if err != nil {
+			p.Close()
+
+			if isConfigError(err) {
+				err = retry.Permanent(err)
+			}
+
+			return fmt.Errorf("cannot ping the postgres database; err: %w", classify(err))
+		}
+
+		pool = p
+		return nil
+	})
+
 	if err != nil {
-		panic(fmt.Errorf("cannot ping the postgres database; error: %v", err))
+		return nil, nil, err
 	}
 
-	tranConn, err := pool.Acquire(context.Background())
-	if err != nil {
-		panic("cannot get connection from the pool")
+	if !ps.SkipMigrations {
+		_, err = migrations.Up(ctx, pool)
+		if err != nil {
+			pool.Close()
+			return nil, nil, fmt.Errorf("cannot migrate the postgres database; err: %w", err)
+		}
+	}
+
+	passwordParams := DefaultPasswordParams()
+	if ps.PasswordParams != nil {
+		passwordParams = *ps.PasswordParams
+	}
+
+	return &postgresClient{pool: pool, passwordParams: passwordParams, logger: opts.Logger}, NewTransactionExecutor(pool), nil
+}
+
+func (ps *PostgreSettings) applyPoolSettings(config *pgxpool.Config) {
+	if ps.MaxConns > 0 {
+		config.MaxConns = ps.MaxConns
 	}
 
-	err = tranConn.Ping(context.Background())
+	if ps.MinConns > 0 {
+		config.MinConns = ps.MinConns
+	}
+
+	if ps.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = ps.MaxConnIdleTime
+	}
+
+	if ps.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = ps.MaxConnLifetime
+	}
+}
+
+// Close waits until every acquired connection is released and closes the pool.
+// Both the PostgresHandler and the TransactionExecutor are unusable afterwards.
+func (pc *postgresClient) Close() {
+	pc.pool.Close()
+}
+
+// query, queryRow and exec run outside of a transaction and classify driver errors the way Tx does.
+
+func (pc *postgresClient) query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := pc.pool.Query(ctx, query, args...)
 	if err != nil {
-		panic(fmt.Errorf("cannot ping the postgres database; error: %v", err))
+		return nil, classify(err)
 	}
 
-	return &postgresClient{pgConn}, NewTransactionExecutor(tranConn)
+	return classifiedRows{rows}, nil
+}
+
+func (pc *postgresClient) queryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return classifiedRow{pc.pool.QueryRow(ctx, query, args...)}
+}
+
+func (pc *postgresClient) exec(ctx context.Context, query string, args ...interface{}) error {
+	_, err := pc.pool.Exec(ctx, query, args...)
+	return classify(err)
 }
 
-func (pc *postgresClient) GetCurrencies() (map[string]float64, error) {
-	res := make(map[string]float64)
+func (pc *postgresClient) GetCurrencies(ctx context.Context) (map[string]decimal.Decimal, error) {
+	res := make(map[string]decimal.Decimal)
 
-	rows, err := pc.connection.Query(context.Background(), "SELECT * FROM currencies")
+	rows, err := pc.query(ctx, "SELECT currency, value FROM currencies")
 	if err != nil {
-		return nil, fmt.Errorf("cannot get currencies from the postgres database; err: %v", err)
+		return nil, fmt.Errorf("cannot get currencies from the postgres database; err: %w", err)
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var currency string
-		var value float64
+		var value decimal.Decimal
 		err = rows.Scan(&currency, &value)
 
 		if err != nil {
-			return nil, fmt.Errorf("cannot scan value from the postgres database; err: %v", err)
+			return nil, fmt.Errorf("cannot scan value from the postgres database; err: %w", err)
 		}
 
 		res[currency] = value
@@ -98,8 +215,8 @@ func (pc *postgresClient) GetCurrencies() (map[string]float64, error) {
 	return res, nil
 }
 
-func (pc *postgresClient) UpdateCurrency(currency string, value float64) error {
-	_, err := pc.connection.Exec(context.Background(),
+func (pc *postgresClient) UpdateCurrency(ctx context.Context, currency string, value decimal.Decimal) error {
+	err := pc.exec(ctx,
 		`UPDATE currencies
 		 SET value = $1
 		 WHERE currency = $2`,
@@ -107,28 +224,28 @@ func (pc *postgresClient) UpdateCurrency(currency string, value float64) error {
 		currency)
 
 	if err != nil {
-		return fmt.Errorf("postgres can not update currency %v to the new value %v; err: %v", currency, value, err)
+		return fmt.Errorf("postgres can not update currency %v to the new value %v; err: %w", currency, value, err)
 	}
 
 	return nil
 }
 
-func (pc *postgresClient) GetUsersNum() (int, error) {
+func (pc *postgresClient) GetUsersNum(ctx context.Context) (int, error) {
 	res := 0
-	err := pc.connection.QueryRow(context.Background(), "SELECT COUNT(id) FROM users").Scan(&res)
+	err := pc.queryRow(ctx, "SELECT COUNT(id) FROM users").Scan(&res)
 
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return 0, fmt.Errorf("cann get number of users from the postgres database; error: %v", err)
+		return 0, fmt.Errorf("cann get number of users from the postgres database; error: %w", err)
 	}
 
 	return res, nil
 }
 
-func (pc *postgresClient) GetCurrencyAmount(currency string) (float64, error) {
-	amount := float64(0)
-	err := pc.connection.QueryRow(
-		context.Background(),
-		`SELECT SUM(amount)
+func (pc *postgresClient) GetCurrencyAmount(ctx context.Context, currency string) (decimal.Decimal, error) {
+	amount := decimal.Zero
+	err := pc.queryRow(
+		ctx,
+		`SELECT COALESCE(SUM(amount), 0)
 		 FROM users_money
 		 WHERE currency = $1`,
 		currency,
@@ -136,76 +253,287 @@ func (pc *postgresClient) GetCurrencyAmount(currency string) (float64, error) {
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, err
+			return decimal.Zero, err
 		}
 
-		return 0, fmt.Errorf("postgres cannot return amount of the currency %v; err: %v", currency, err)
+		return decimal.Zero, fmt.Errorf("postgres cannot return amount of the currency %v; err: %w", currency, err)
 	}
 
 	return amount, nil
 }
 
-func (pc *postgresClient) GetCurrencyValue(currency string) (float64, error) {
-	row := pc.connection.QueryRow(
-		context.Background(),
+func (pc *postgresClient) GetCurrencyValue(ctx context.Context, currency string) (decimal.Decimal, error) {
+	row := pc.queryRow(
+		ctx,
 		`SELECT value 
 		 FROM currencies 
 		 WHERE currency = $1`,
 		currency,
 	)
 
-	value := float64(0)
+	value := decimal.Zero
 	err := row.Scan(&value)
 	if err != nil {
-		return 0, fmt.Errorf("cannot get currencies'(%v) value; err: %v", currency, err)
+		return decimal.Zero, fmt.Errorf("cannot get currencies'(%v) value; err: %w", currency, err)
 	}
 
 	return value, nil
 }
 
-func (pc *postgresClient) UpdateCurrencyAmount(userID uint64, currency string, value float64) error {
-	_, err := pc.connection.Exec(
-		context.Background(),
-		`
-		 INSERT INTO users_money (amount, user_id, currency)
-		 VALUES($1, $2, $3)
-		 ON CONFLICT (user_id, currency)
-		 DO UPDATE 
-		 SET amount = EXCLUDED.amount`,
-		value,
-		userID,
-		currency,
+func (pc *postgresClient) GetCurrencyPrecision(ctx context.Context, currency string) (int32, error) {
+	return currencyPrecision(ctx, pc.pool, currency)
+}
+
+// UpdateCurrencyAmount sets the user's balance. The difference to the previous balance
+// is booked against the system account.
+func (pc *postgresClient) UpdateCurrencyAmount(ctx context.Context, userID uint64, currency string, value decimal.Decimal) error {
+	value, err := roundToCurrency(ctx, pc.pool, currency, value)
+	if err != nil {
+		return err
+	}
+
+	err = NewTransactionExecutor(pc.pool).WithTx(ctx, func(tx Tx) error {
+		previous := decimal.Zero
+
+		err := tx.QueryRow(
+			ctx,
+			`SELECT amount
+			 FROM users_money
+			 WHERE user_id = $1
+			 AND currency = $2
+			 FOR UPDATE`,
+			userID,
+			currency,
+		).Scan(&previous)
+
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		err = tx.Exec(
+			ctx,
+			`
+			 INSERT INTO users_money (amount, user_id, currency)
+			 VALUES($1, $2, $3)
+			 ON CONFLICT (user_id, currency)
+			 DO UPDATE 
+			 SET amount = EXCLUDED.amount`,
+			value,
+			userID,
+			currency,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		if value.Equal(previous) {
+			return nil
+		}
+
+		return moveMoney(ctx, tx, LedgerReference{Type: LedgerRefAdjustment}, currency, value.Sub(previous), systemAccount(), availableAccount(userID))
+	})
+
+	if err != nil {
+		return fmt.Errorf("cannot update user's (id = %v) currency (%v); err: %w", userID, currency, err)
+	}
+
+	return nil
+}
+
+// AddUser stores the user with the argon2id hash of the password.
+func (pc *postgresClient) AddUser(ctx context.Context, email, password string) error {
+	hash, err := hashPassword(password, pc.passwordParams)
+	if err != nil {
+		return err
+	}
+
+	err = pc.exec(
+		ctx,
+		`INSERT INTO users (email, pass, pass_hashed)
+		 VALUES($1, $2, true)`,
+		email,
+		hash,
 	)
 
 	if err != nil {
-		return fmt.Errorf("cannot update user's (id = %v) currency (%v); err: %v", userID, currency, err)
+		return fmt.Errorf("cannot update user's (email: %v) data; err: %w", email, err)
 	}
 
 	return nil
 }
 
-func (pc *postgresClient) AddUser(email, password string) error {
-	_, err := pc.connection.Exec(
-		context.Background(),
-		`INSERT INTO users (email, pass)
-		 VALUES($1, $2)`,
+// VerifyCredentials returns the ID of the user if the password is right and ErrInvalidCredentials
+// if the user does not exist or the password is wrong; which of the two it was is only logged.
+// Plaintext passwords and hashes made with other parameters than the current ones are replaced with new hashes.
+func (pc *postgresClient) VerifyCredentials(ctx context.Context, email, password string) (uint64, error) {
+	id := uint64(0)
+	hash := ""
+	hashed := false
+
+	err := pc.queryRow(
+		ctx,
+		`SELECT id, pass, pass_hashed
+		 FROM users
+		 WHERE email = $1`,
 		email,
-		password,
+	).Scan(&id, &hash, &hashed)
+
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			return 0, fmt.Errorf("cannot get credentials of the user (email = %v); err: %w", email, err)
+		}
+
+		// hash anyway, so that the response time does not tell whether the email is registered
+		hashPassword(password, pc.passwordParams)
+		pc.logf("sign in with an unknown email %v", email)
+		return 0, ErrInvalidCredentials
+	}
+
+	ok, needsRehash, err := verifyPassword(password, hash, hashed, pc.passwordParams)
+	if err != nil {
+		return 0, fmt.Errorf("cannot verify credentials of the user (id = %v); err: %w", id, err)
+	}
+
+	if !ok {
+		pc.logf("sign in with a wrong password of the user (id = %v)", id)
+		return 0, ErrInvalidCredentials
+	}
+
+	if needsRehash {
+		// a failed rehash is retried on the next sign in; it must not fail this one
+		err = pc.rehashPassword(ctx, id, password, hash)
+		if err != nil {
+			pc.logf("%v", err)
+		}
+	}
+
+	return id, nil
+}
+
+// rehashPassword replaces the old hash unless the password was changed concurrently.
+func (pc *postgresClient) rehashPassword(ctx context.Context, userID uint64, password, oldHash string) error {
+	hash, err := hashPassword(password, pc.passwordParams)
+	if err != nil {
+		return err
+	}
+
+	err = pc.exec(
+		ctx,
+		`UPDATE users
+		 SET pass = $1, pass_hashed = true
+		 WHERE id = $2
+		 AND pass = $3`,
+		hash,
+		userID,
+		oldHash,
 	)
 
 	if err != nil {
-		return fmt.Errorf("cannot update user's (email: %v, password: %v) data; err: %v", email, password, err)
+		return fmt.Errorf("cannot rehash the password of the user (id = %v); err: %w", userID, err)
 	}
 
 	return nil
 }
 
-func (pc *postgresClient) GetUserData(email string) (uint64, string, error) {
+// HashPlaintextPasswords replaces the plaintext passwords of earlier versions with argon2id hashes and
+// returns how many it replaced. Every batchSize passwords are hashed in a transaction of their own,
+// so it can run alongside sign ins and be stopped at any time.
+func (pc *postgresClient) HashPlaintextPasswords(ctx context.Context, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("%w; batch size must be positive, got %v", ErrInvalidAmount, batchSize)
+	}
+
+	total := 0
+	te := NewTransactionExecutor(pc.pool)
+
+	for {
+		hashed := 0
+
+		err := te.WithTx(ctx, func(tx Tx) error {
+			hashed = 0
+
+			rows, err := tx.Query(
+				ctx,
+				`SELECT id, pass
+				 FROM users
+				 WHERE NOT pass_hashed
+				 ORDER BY id
+				 LIMIT $1
+				 FOR UPDATE SKIP LOCKED`,
+				batchSize,
+			)
+
+			if err != nil {
+				return err
+			}
+
+			ids := make([]uint64, 0, batchSize)
+			passwords := make([]string, 0, batchSize)
+
+			for rows.Next() {
+				id, password := uint64(0), ""
+
+				err = rows.Scan(&id, &password)
+				if err != nil {
+					rows.Close()
+					return err
+				}
+
+				ids = append(ids, id)
+				passwords = append(passwords, password)
+			}
+
+			rows.Close()
+			if rows.Err() != nil {
+				return rows.Err()
+			}
+
+			for i, id := range ids {
+				hash, err := hashPassword(passwords[i], pc.passwordParams)
+				if err != nil {
+					return err
+				}
+
+				err = tx.Exec(
+					ctx,
+					`UPDATE users
+					 SET pass = $1, pass_hashed = true
+					 WHERE id = $2`,
+					hash,
+					id,
+				)
+
+				if err != nil {
+					return err
+				}
+			}
+
+			hashed = len(ids)
+			return nil
+		})
+
+		if err != nil {
+			return total, fmt.Errorf("cannot hash plaintext passwords (%v hashed so far); err: %w", total, err)
+		}
+
+		total += hashed
+
+		if hashed < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// GetUserData returns the ID and the password hash of the user.
+//
+// Deprecated: hashes cannot be compared with passwords directly; use VerifyCredentials.
+func (pc *postgresClient) GetUserData(ctx context.Context, email string) (uint64, string, error) {
 	id := uint64(0)
 	password := ""
 
-	row := pc.connection.QueryRow(
-		context.Background(),
+	row := pc.queryRow(
+		ctx,
 		`SELECT id, pass 
 		 FROM users 
 		 WHERE email = $1`,
@@ -219,15 +547,15 @@ func (pc *postgresClient) GetUserData(email string) (uint64, string, error) {
 			return 0, "", err
 		}
 
-		return 0, "", fmt.Errorf("postgres cannot return user's data (email = %v); err: %v", email, err)
+		return 0, "", fmt.Errorf("postgres cannot return user's data (email = %v); err: %w", email, err)
 	}
 
 	return id, password, nil
 }
 
-func (pc *postgresClient) GetUserMoney(userID uint64, currency string) (float64, error) {
-	rows := pc.connection.QueryRow(
-		context.Background(),
+func (pc *postgresClient) GetUserMoney(ctx context.Context, userID uint64, currency string) (decimal.Decimal, error) {
+	rows := pc.queryRow(
+		ctx,
 		`SELECT amount 
 		 FROM users_money
 		 WHERE user_id = $1
@@ -236,135 +564,93 @@ func (pc *postgresClient) GetUserMoney(userID uint64, currency string) (float64,
 		currency,
 	)
 
-	amount := float64(0)
+	amount := decimal.Zero
 
 	err := rows.Scan(&amount)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, err
+			return decimal.Zero, err
 		}
 
-		return 0, fmt.Errorf("postgres cannot scan user's (id = %v) amount of the currency (%v); err: %v", userID, currency, err)
+		return decimal.Zero, fmt.Errorf("postgres cannot scan user's (id = %v) amount of the currency (%v); err: %w", userID, currency, err)
 	}
 
 	return amount, nil
 }
 
-func (pc *postgresClient) FindSellers(tx TransactionExecutor, currency string, amountToBuy float64, floorPrice, ceilPrice float64) ([]*SellingInfo, error) {
-	rows, err := tx.Query(
-		`SELECT users_money.user_id, selling.amount, selling.price
-		 FROM users_money 
-		 	JOIN selling 
-			ON selling.user_id = users_money.user_id
-		 WHERE users_money.currency = $1
-		 AND selling.price BETWEEN $2 AND $3
-		 ORDER BY selling.price`,
-		currency,
-		floorPrice,
-		ceilPrice,
+// FindSellers builds a fill plan for buying amountToBuy of the currency from asks priced between
+// floorPrice and ceilPrice. Asks are taken cheapest first, the oldest first within one price,
+// and the last one is only partially used if needed. Only the planned asks are locked, until the end
+// of tx, and asks locked by concurrent buyers are skipped, so two buyers never plan to take the same ask
+// and each of them can still plan with the asks the other one does not need.
+// It returns ErrNoLiquidity if the asks that are not locked cannot cover the whole amount.
+func (pc *postgresClient) FindSellers(ctx context.Context, tx Tx, currency string, amountToBuy decimal.Decimal, floorPrice, ceilPrice decimal.Decimal) ([]*SellingInfo, error) {
+	amountToBuy, err := roundToCurrency(ctx, tx, currency, amountToBuy)
+	if err != nil {
+		return nil, err
+	}
+
+	asks, err := lockOrdersToCover(
+		ctx,
+		tx,
+		&bookQuery{currency: currency, side: OrderSideAsk, minPrice: &floorPrice, maxPrice: &ceilPrice, skipLocked: true},
+		amountToBuy,
 	)
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
-		}
-
-		tx.Rollback()
 		return nil, fmt.Errorf("postgres cannot find sellers for the currency %v (amount %v); err: %w", currency, amountToBuy, err)
 	}
 
-	sellers := make([]*SellingInfo, 0)
-	sum := float64(0)
-	skip := false
+	sellers := make([]*SellingInfo, 0, len(asks))
+	remaining := amountToBuy
 
-	for rows.Next() {
-		if skip {
-			continue
+	for _, ask := range asks {
+		if !remaining.IsPositive() {
+			break
 		}
 
-		sellerID := uint64(0)
-		sellerMoneyAmount := float64(0)
-		price := float64(0)
-
-		err = rows.Scan(&sellerID, &sellerMoneyAmount, &price)
-		if err != nil {
-			return nil, fmt.Errorf("pgx cannot scan userID or users_money.amount; err: %w", err)
-		}
-
-		sum += sellerMoneyAmount
-
-		if sum >= amountToBuy {
-			sellers = append(sellers, &SellingInfo{
-				UserID:   sellerID,
-				Amount:   sellerMoneyAmount - (sum - amountToBuy),
-				Price:    price,
-				Currency: currency,
-			})
-
-			skip = true
-			continue
+		seller := &SellingInfo{
+			OrderID:  ask.ID,
+			UserID:   ask.UserID,
+			Amount:   decimal.Min(ask.Amount, remaining),
+			Currency: currency,
+			Price:    ask.Price,
 		}
 
-		sellers = append(sellers, &SellingInfo{
-			UserID:   sellerID,
-			Amount:   sellerMoneyAmount,
-			Price:    price,
-			Currency: currency,
-		})
+		remaining = remaining.Sub(seller.Amount)
+		sellers = append(sellers, seller)
 	}
 
-	if sum < amountToBuy {
-		return nil, nil
+	if remaining.IsPositive() {
+		return nil, fmt.Errorf("%w; asks of the currency %v priced between %v and %v cannot cover %v", ErrNoLiquidity, currency, floorPrice, ceilPrice, amountToBuy)
 	}
 
 	return sellers, nil
 }
 
-func (pc *postgresClient) SendMoney(tx TransactionExecutor, senderID, receiverID uint64, currency string, value float64) error {
-	userMoney := float64(0)
-
-	rows, err := tx.Query(
-		`SELECT amount 
-		 FROM users_money 
-		 WHERE currency = $1
-		 AND user_id = $2`,
-		currency,
-		senderID,
-	)
+// SendMoney settles a purchase: value of the currency goes from the seller (senderID) to the buyer (receiverID)
+// at the given price. The purchase is recorded in the trades table and its ID is returned.
+// It fails with *InsufficientFundsError if the seller does not have value, and with ErrInvalidAmount
+// if nothing is left of value after truncating it to the currency precision.
+func (pc *postgresClient) SendMoney(ctx context.Context, tx Tx, senderID, receiverID uint64, currency string, value, price decimal.Decimal) (uint64, error) {
+	requested := value
 
+	value, err := roundToCurrency(ctx, tx, currency, value)
 	if err != nil {
-		tx.Rollback()
-
-		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("%w; user with id %v does not have %v %v", pgx.ErrNoRows, senderID, value, currency)
-		}
-
-		return fmt.Errorf("cannot get %v %v from the users_money table; err: %v", value, currency, err)
+		return 0, err
 	}
 
-	for rows.Next() {
-		err = rows.Scan(&userMoney)
-		if err != nil {
-			return err
-		}
+	if !value.IsPositive() {
+		return 0, fmt.Errorf("%w; cannot send %v %v from the user (id = %v)", ErrInvalidAmount, requested, currency, senderID)
 	}
 
-	err = tx.Exec(
-		`UPDATE users_money
-		 SET amount = $1
-		 WHERE user_id = $2
-		 AND currency = $3`,
-		userMoney-value,
-		senderID,
-		currency,
-	)
-
+	err = debitUser(ctx, tx, senderID, currency, value)
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("cannot sell user's (id = %v) currency(%s); err: %v", senderID, currency, err)
+		return 0, err
 	}
 
 	err = tx.Exec(
+		ctx,
 		`
 		 INSERT into users_money (user_id, currency, amount)
 		 VALUES ($1, $2, $3)
@@ -377,115 +663,141 @@ func (pc *postgresClient) SendMoney(tx TransactionExecutor, senderID, receiverID
 	)
 
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("cannot update currency amount; err: %v", err)
+		return 0, fmt.Errorf("cannot update currency amount; err: %w", err)
 	}
 
-	return nil
-}
-
-func (pc *postgresClient) AddMoneyToSellingPool(tx TransactionExecutor, currency string, userID uint64, amount, price float64) error {
-	err := tx.Exec(
-		`INSERT INTO selling (currency, user_id, amount, price)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (user_id, currency, price) 
-		DO UPDATE 
-		SET amount = selling.amount + EXCLUDED.amount`,
-		currency,
-		userID,
-		amount,
-		price)
+	trade := &Trade{
+		BuyerID:  receiverID,
+		SellerID: senderID,
+		Currency: currency,
+		Price:    price,
+		Amount:   value,
+	}
 
+	err = insertTrade(ctx, tx, trade)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return 0, err
 	}
 
-	rows, err := tx.Query(
-		`SELECT amount 
-		 FROM users_money 
-		 WHERE currency = $1
-		 AND user_id = $2`,
-		currency,
-		userID,
-	)
-
+	err = moveMoney(ctx, tx, LedgerReference{Type: LedgerRefTrade, ID: trade.ID}, currency, value, availableAccount(senderID), availableAccount(receiverID))
 	if err != nil {
-		tx.Rollback()
+		return 0, err
+	}
 
-		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("%w; user with id %v does not have %v %v", pgx.ErrNoRows, userID, amount, currency)
-		}
+	return trade.ID, nil
+}
 
-		return fmt.Errorf("cannot get %v %v from the users_money table; err: %v", amount, currency, err)
+// AddMoneyToSellingPool places an ask: amount is taken from the user's balance and offered at price.
+// It fails with *InsufficientFundsError if the user does not have amount and with ErrInvalidAmount
+// if the price or amount is not positive.
+func (pc *postgresClient) AddMoneyToSellingPool(ctx context.Context, tx Tx, currency string, userID uint64, amount, price decimal.Decimal) error {
+	if !price.IsPositive() {
+		return fmt.Errorf("%w; cannot offer %v %v of the user (id = %v) at %v", ErrInvalidAmount, amount, currency, userID, price)
 	}
 
-	userHas := float64(0)
-	for rows.Next() {
-		err = rows.Scan(&userHas)
-		if err != nil {
-			return err
-		}
+	amount, err := roundToCurrency(ctx, tx, currency, amount)
+	if err != nil {
+		return err
 	}
 
-	err = tx.Exec(
-		`UPDATE users_money
-	 	 SET amount = $1
-	 	 WHERE user_id = $2
-	 	 AND currency = $3`,
-		userHas-amount,
-		userID,
+	err = debitUser(ctx, tx, userID, currency, amount)
+	if err != nil {
+		return err
+	}
+
+	orderID := uint64(0)
+	err = tx.QueryRow(
+		ctx,
+		`INSERT INTO selling (currency, user_id, amount, price, side)
+		VALUES ($1, $2, $3, $4, 'ask')
+		RETURNING id`,
 		currency,
-	)
+		userID,
+		amount,
+		price).Scan(&orderID)
 
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	return tx.Commit()
+	return moveMoney(ctx, tx, LedgerReference{Type: LedgerRefOrder, ID: orderID}, currency, amount, availableAccount(userID), escrowAccount(userID))
 }
 
-func (pc *postgresClient) GetMoneyFromSellingPool(tx TransactionExecutor, currency string, userID uint64, amount, floorPrice, ceilPrice float64) error {
-	err := tx.Exec(
-		`CREATE VIEW buf AS 
-		 SELECT id, amount 
-		 FROM selling 
-		 WHERE user_id = $1
-		 AND currency = $2 
-		 AND price BETWEEN $4 AND $5 
-		 ORDER BY price
-		 LIMIT 1;
-
-		 UPDATE selling
-		 SET amount = (SELECT amount FROM buf) - $3
-		 WHERE id = (SELECT id FROM buf);
-		 
-		 DROP VIEW buf;`,
-		userID,
-		currency,
-		amount,
-		floorPrice,
-		ceilPrice)
+// GetMoneyFromSellingPool takes amount back from the user's cheapest ask priced between floorPrice
+// and ceilPrice and returns it to the user's balance. The ask is reduced, or removed once it is empty,
+// in a single statement. It returns the amount that was moved, which is amount truncated to the
+// currency precision, or *InsufficientPoolError if the ask does not hold that much.
+// It fails with ErrInvalidAmount if nothing is left of amount after truncating.
+func (pc *postgresClient) GetMoneyFromSellingPool(ctx context.Context, tx Tx, currency string, userID uint64, amount, floorPrice, ceilPrice decimal.Decimal) (decimal.Decimal, error) {
+	requested := amount
 
+	amount, err := roundToCurrency(ctx, tx, currency, amount)
 	if err != nil {
-		return err
+		return decimal.Zero, err
 	}
 
-	err = tx.Exec(
-		`INSERT INTO users_money (user_id, currency, amount)
-		 VALUES ($1, $2, $3)
-		 ON CONFLICT (user_id, currency)
-		 DO UPDATE 
-		 SET amount = users_money.amount + EXCLUDED.amount`,
+	if !amount.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%w; cannot take %v %v from the user's (id = %v) selling pool", ErrInvalidAmount, requested, currency, userID)
+	}
+
+	orderID := uint64(0)
+	available := decimal.Zero
+
+	err = tx.QueryRow(
+		ctx,
+		`WITH chosen AS (
+			SELECT id, amount
+			FROM selling
+			WHERE user_id = $1
+			AND currency = $2
+			AND side = 'ask'
+			AND price BETWEEN $4 AND $5
+			ORDER BY price, created_at, id
+			LIMIT 1
+			FOR UPDATE
+		 ), reduced AS (
+			UPDATE selling
+			SET amount = selling.amount - $3
+			FROM chosen
+			WHERE selling.id = chosen.id
+			AND chosen.amount > $3
+		 ), removed AS (
+			DELETE FROM selling
+			USING chosen
+			WHERE selling.id = chosen.id
+			AND chosen.amount = $3
+		 )
+		 SELECT id, amount FROM chosen`,
 		userID,
 		currency,
 		amount,
-	)
+		floorPrice,
+		ceilPrice,
+	).Scan(&orderID, &available)
+
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return decimal.Zero, fmt.Errorf("cannot take %v %v from the user's (id = %v) selling pool; err: %w", amount, currency, userID, err)
+	}
+
+	if available.LessThan(amount) {
+		return decimal.Zero, &InsufficientPoolError{Currency: currency, Requested: amount, Available: available}
+	}
 
+	err = creditUser(ctx, tx, userID, currency, amount)
 	if err != nil {
-		return err
+		return decimal.Zero, err
 	}
 
-	return nil
+	err = moveMoney(ctx, tx, LedgerReference{Type: LedgerRefOrder, ID: orderID}, currency, amount, escrowAccount(userID), availableAccount(userID))
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return amount, nil
+}
+
+func (pc *postgresClient) logf(format string, v ...interface{}) {
+	if pc.logger != nil {
+		pc.logger.Printf(format, v...)
+	}
 }
@@ -0,0 +1,269 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+type testAsk struct {
+	user   int // index of the test user
+	price  string
+	amount string
+}
+
+// placeAsks puts the asks into the selling pool in the given order.
+func placeAsks(t *testing.T, pc *postgresClient, te TransactionExecutor, userIDs []uint64, currency string, asks ...testAsk) {
+	t.Helper()
+
+	for _, ask := range asks {
+		err := te.WithTx(context.Background(), func(tx Tx) error {
+			return pc.AddMoneyToSellingPool(context.Background(), tx, currency, userIDs[ask.user], decimal.RequireFromString(ask.amount), decimal.RequireFromString(ask.price))
+		})
+
+		if err != nil {
+			t.Fatalf("cannot place ask; err: %v", err)
+		}
+	}
+}
+
+func formatPlan(sellers []*SellingInfo) []string {
+	plan := make([]string, 0, len(sellers))
+	for _, seller := range sellers {
+		plan = append(plan, fmt.Sprintf("%v@%v", seller.Amount, seller.Price))
+	}
+
+	return plan
+}
+
+func TestFindSellers(t *testing.T) {
+	pc, te := newTestClient(t)
+
+	tests := []struct {
+		name       string
+		asks       []testAsk
+		amount     string
+		floorPrice string
+		ceilPrice  string
+		wantPlan   []string // "<amount>@<price>"
+		wantErr    error
+	}{
+		{
+			name:       "single ask covers the amount",
+			asks:       []testAsk{{0, "1", "10"}},
+			amount:     "4",
+			floorPrice: "0",
+			ceilPrice:  "10",
+			wantPlan:   []string{"4@1"},
+		},
+		{
+			name:       "cheapest asks first and the last one partially",
+			asks:       []testAsk{{0, "3", "10"}, {1, "1", "5"}, {0, "2", "5"}},
+			amount:     "12",
+			floorPrice: "0",
+			ceilPrice:  "10",
+			wantPlan:   []string{"5@1", "5@2", "2@3"},
+		},
+		{
+			name:       "oldest ask first within one price",
+			asks:       []testAsk{{1, "1", "3"}, {0, "1", "5"}},
+			amount:     "4",
+			floorPrice: "0",
+			ceilPrice:  "10",
+			wantPlan:   []string{"3@1", "1@1"},
+		},
+		{
+			name:       "asks outside of the price range are left out",
+			asks:       []testAsk{{0, "1", "10"}, {1, "2", "10"}, {0, "5", "10"}},
+			amount:     "10",
+			floorPrice: "2",
+			ceilPrice:  "4",
+			wantPlan:   []string{"10@2"},
+		},
+		{
+			name:       "amount is truncated to the currency precision",
+			asks:       []testAsk{{0, "1", "10"}},
+			amount:     "1.239",
+			floorPrice: "0",
+			ceilPrice:  "10",
+			wantPlan:   []string{"1.23@1"},
+		},
+		{
+			name:       "asks that cannot cover the amount",
+			asks:       []testAsk{{0, "1", "5"}, {1, "2", "5"}},
+			amount:     "11",
+			floorPrice: "0",
+			ceilPrice:  "10",
+			wantErr:    ErrNoLiquidity,
+		},
+		{
+			name:       "empty pool",
+			amount:     "1",
+			floorPrice: "0",
+			ceilPrice:  "10",
+			wantErr:    ErrNoLiquidity,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			currency := testCurrency(t, pc, 2)
+			userIDs := []uint64{testUser(t, pc), testUser(t, pc)}
+
+			for _, userID := range userIDs {
+				setBalance(t, pc, userID, currency, "100")
+			}
+
+			placeAsks(t, pc, te, userIDs, currency, tt.asks...)
+
+			var sellers []*SellingInfo
+			err := te.WithTx(context.Background(), func(tx Tx) error {
+				var err error
+				sellers, err = pc.FindSellers(context.Background(), tx, currency, decimal.RequireFromString(tt.amount), decimal.RequireFromString(tt.floorPrice), decimal.RequireFromString(tt.ceilPrice))
+				return err
+			})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("FindSellers() err = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("FindSellers() err = %v", err)
+			}
+
+			if got := formatPlan(sellers); fmt.Sprint(got) != fmt.Sprint(tt.wantPlan) {
+				t.Errorf("FindSellers() = %v, want %v", got, tt.wantPlan)
+			}
+		})
+	}
+}
+
+func TestFindSellersLeavesUnneededAsksToOtherBuyers(t *testing.T) {
+	pc, te := newTestClient(t)
+	ctx := context.Background()
+
+	currency := testCurrency(t, pc, 2)
+	userIDs := []uint64{testUser(t, pc), testUser(t, pc)}
+
+	for _, userID := range userIDs {
+		setBalance(t, pc, userID, currency, "100")
+	}
+
+	placeAsks(t, pc, te, userIDs, currency, testAsk{0, "1", "10"}, testAsk{1, "2", "10"})
+
+	first, err := te.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() err = %v", err)
+	}
+	defer first.Rollback(ctx)
+
+	sellers, err := pc.FindSellers(ctx, first, currency, decimal.RequireFromString("5"), decimal.Zero, decimal.RequireFromString("10"))
+	if err != nil {
+		t.Fatalf("first FindSellers() err = %v", err)
+	}
+
+	if got, want := formatPlan(sellers), []string{"5@1"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("first FindSellers() = %v, want %v", got, want)
+	}
+
+	second, err := te.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() err = %v", err)
+	}
+	defer second.Rollback(ctx)
+
+	sellers, err = pc.FindSellers(ctx, second, currency, decimal.RequireFromString("10"), decimal.Zero, decimal.RequireFromString("10"))
+	if err != nil {
+		t.Fatalf("second FindSellers() err = %v", err)
+	}
+
+	if got, want := formatPlan(sellers), []string{"10@2"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("second FindSellers() = %v, want %v", got, want)
+	}
+}
+
+func TestAddMoneyToSellingPoolRejectsNonPositiveAmounts(t *testing.T) {
+	pc, te := newTestClient(t)
+
+	tests := []struct {
+		name   string
+		price  string
+		amount string
+	}{
+		{"zero price", "0", "10"},
+		{"negative price", "-1", "10"},
+		{"zero amount", "1", "0"},
+		{"negative amount", "1", "-10"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			currency := testCurrency(t, pc, 2)
+			userIDs := []uint64{testUser(t, pc)}
+			setBalance(t, pc, userIDs[0], currency, "100")
+
+			err := te.WithTx(context.Background(), func(tx Tx) error {
+				return pc.AddMoneyToSellingPool(context.Background(), tx, currency, userIDs[0], decimal.RequireFromString(tt.amount), decimal.RequireFromString(tt.price))
+			})
+
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Fatalf("AddMoneyToSellingPool() err = %v, want %v", err, ErrInvalidAmount)
+			}
+
+			requireBalance(t, pc, userIDs[0], currency, "100")
+
+			_, asks, err := pc.GetOrderBook(context.Background(), currency, 10)
+			if err != nil {
+				t.Fatalf("GetOrderBook() err = %v", err)
+			}
+
+			if len(asks) != 0 {
+				t.Errorf("book has %v asks, want none", len(asks))
+			}
+		})
+	}
+}
+
+func TestGetMoneyFromSellingPoolRejectsNonPositiveAmounts(t *testing.T) {
+	pc, te := newTestClient(t)
+
+	for _, amount := range []string{"0", "-5", "0.001"} {
+		amount := amount
+
+		t.Run(amount, func(t *testing.T) {
+			t.Parallel()
+
+			currency := testCurrency(t, pc, 2)
+			userIDs := []uint64{testUser(t, pc)}
+			setBalance(t, pc, userIDs[0], currency, "100")
+			placeAsks(t, pc, te, userIDs, currency, testAsk{0, "1", "10"})
+
+			err := te.WithTx(context.Background(), func(tx Tx) error {
+				_, err := pc.GetMoneyFromSellingPool(context.Background(), tx, currency, userIDs[0], decimal.RequireFromString(amount), decimal.Zero, decimal.RequireFromString("10"))
+				return err
+			})
+
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Fatalf("GetMoneyFromSellingPool() err = %v, want %v", err, ErrInvalidAmount)
+			}
+
+			requireBalance(t, pc, userIDs[0], currency, "90")
+			requireReconciled(t, pc, userIDs...)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultTradesPageSize = 50
+	maxTradesPageSize     = 500
+)
+
+type Trade struct {
+	ID        uint64
+	BuyerID   uint64
+	SellerID  uint64
+	Currency  string
+	Price     decimal.Decimal
+	Amount    decimal.Decimal
+	CreatedAt time.Time
+}
+
+// TradeFilter selects a page of the user's trades. Zero values mean "no filter";
+// From is inclusive and To is exclusive.
+type TradeFilter struct {
+	Currency  string
+	From      time.Time
+	To        time.Time
+	PageSize  int
+	PageToken string // NextPageToken of the previous page; empty for the first page
+}
+
+type TradesPage struct {
+	Trades        []*Trade
+	NextPageToken string // empty on the last page
+}
+
+// GetUserTrades returns trades where the user bought or sold, newest first.
+func (pc *postgresClient) GetUserTrades(ctx context.Context, userID uint64, filter TradeFilter) (*TradesPage, error) {
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultTradesPageSize
+	}
+
+	if pageSize > maxTradesPageSize {
+		pageSize = maxTradesPageSize
+	}
+
+	var cursorTime *time.Time
+	cursorID := uint64(0)
+
+	if filter.PageToken != "" {
+		t, id, err := decodeTradesCursor(filter.PageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		cursorTime, cursorID = &t, id
+	}
+
+	rows, err := pc.query(
+		ctx,
+		`SELECT id, buyer_id, seller_id, currency, price, amount, created_at
+		 FROM trades
+		 WHERE (buyer_id = $1 OR seller_id = $1)
+		 AND ($2 = '' OR currency = $2)
+		 AND ($3::TIMESTAMPTZ IS NULL OR created_at >= $3)
+		 AND ($4::TIMESTAMPTZ IS NULL OR created_at < $4)
+		 AND ($5::TIMESTAMPTZ IS NULL OR (created_at, id) < ($5, $6))
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $7`,
+		userID,
+		filter.Currency,
+		nullableTime(filter.From),
+		nullableTime(filter.To),
+		cursorTime,
+		cursorID,
+		pageSize+1,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot get trades of the user (id = %v); err: %w", userID, err)
+	}
+	defer rows.Close()
+
+	page := &TradesPage{Trades: make([]*Trade, 0, pageSize)}
+
+	for rows.Next() {
+		trade := &Trade{}
+
+		err = rows.Scan(&trade.ID, &trade.BuyerID, &trade.SellerID, &trade.Currency, &trade.Price, &trade.Amount, &trade.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("cannot scan trade; err: %w", err)
+		}
+
+		page.Trades = append(page.Trades, trade)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("cannot get trades of the user (id = %v); err: %w", userID, rows.Err())
+	}
+
+	if len(page.Trades) > pageSize {
+		page.Trades = page.Trades[:pageSize]
+
+		last := page.Trades[pageSize-1]
+		page.NextPageToken = encodeTradesCursor(last.CreatedAt, last.ID)
+	}
+
+	return page, nil
+}
+
+func insertTrade(ctx context.Context, tx Tx, trade *Trade) error {
+	err := tx.QueryRow(
+		ctx,
+		`INSERT INTO trades (buyer_id, seller_id, currency, price, amount)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id, created_at`,
+		trade.BuyerID,
+		trade.SellerID,
+		trade.Currency,
+		trade.Price,
+		trade.Amount,
+	).Scan(&trade.ID, &trade.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("cannot record trade of %v %v between users %v and %v; err: %w", trade.Amount, trade.Currency, trade.SellerID, trade.BuyerID, err)
+	}
+
+	return nil
+}
+
+// Page tokens are opaque to callers; they hold the position of the last trade of the page.
+
+func encodeTradesCursor(createdAt time.Time, id uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", createdAt.UnixNano(), id)))
+}
+
+func decodeTradesCursor(token string) (time.Time, uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w %q; err: %v", ErrInvalidPageToken, token, err)
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return time.Time{}, 0, fmt.Errorf("%w %q", ErrInvalidPageToken, token)
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w %q; err: %v", ErrInvalidPageToken, token, err)
+	}
+
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w %q; err: %v", ErrInvalidPageToken, token, err)
+	}
+
+	return time.Unix(0, nanos).UTC(), id, nil
+}
+
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
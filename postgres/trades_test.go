@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeTradesCursor(t *testing.T) {
+	createdAt := time.Date(2022, 9, 1, 12, 30, 0, 123, time.UTC)
+
+	gotTime, gotID, err := decodeTradesCursor(encodeTradesCursor(createdAt, 42))
+	if err != nil {
+		t.Fatalf("decodeTradesCursor() err = %v", err)
+	}
+
+	if !gotTime.Equal(createdAt) || gotID != 42 {
+		t.Errorf("decodeTradesCursor() = %v, %v, want %v, 42", gotTime, gotID, createdAt)
+	}
+
+	for _, token := range []string{
+		"!",
+		base64.RawURLEncoding.EncodeToString([]byte("1")),
+		base64.RawURLEncoding.EncodeToString([]byte("x:1")),
+		base64.RawURLEncoding.EncodeToString([]byte("1:-1")),
+	} {
+		_, _, err = decodeTradesCursor(token)
+		if !errors.Is(err, ErrInvalidPageToken) {
+			t.Errorf("decodeTradesCursor(%q) err = %v, want %v", token, err, ErrInvalidPageToken)
+		}
+	}
+}
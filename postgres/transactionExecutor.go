@@ -2,78 +2,151 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+const (
+	maxTxAttempts  = 5
+	txRetryBackoff = 10 * time.Millisecond
+)
+
+// TransactionExecutor hands out transactions. Every transaction is owned by the
+// operation that began it, so concurrent callers never share a Tx.
 type TransactionExecutor interface {
-	Begin() error
-	Commit() error
-	Rollback() error
-	LockMoney() error
-	Exec(query string, args ...interface{}) error
-	Query(query string, args ...interface{}) (pgx.Rows, error)
+	Begin(ctx context.Context) (Tx, error)
+
+	// WithTx runs fn inside a new transaction. The transaction is committed when fn returns nil
+	// and rolled back when fn returns an error or panics. Serialization failures and deadlocks
+	// are retried with a fresh transaction, so fn must be safe to run more than once.
+	WithTx(ctx context.Context, fn func(tx Tx) error) error
+}
+
+// Tx is a single database transaction. Its errors match the package errors (ErrNotFound and others).
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+	LockMoney(ctx context.Context) error
+	Exec(ctx context.Context, query string, args ...interface{}) error
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
 }
 
 type transExec struct {
-	connection *pgxpool.Conn
-	tx         pgx.Tx
-	isTxBegun  bool
+	pool *pgxpool.Pool
 }
 
-func NewTransactionExecutor(connection *pgxpool.Conn) TransactionExecutor {
-	return &transExec{connection, nil, false}
+type transaction struct {
+	tx pgx.Tx
 }
 
-func (te *transExec) Begin() error {
-	if te.isTxBegun {
-		return nil
+// NewTransactionExecutor returns an executor that takes a fresh connection from the pool
+// for every transaction and gives it back on Commit or Rollback.
+func NewTransactionExecutor(pool *pgxpool.Pool) TransactionExecutor {
+	return &transExec{pool}
+}
+
+func (te *transExec) Begin(ctx context.Context) (Tx, error) {
+	tx, err := te.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start transaction; err: %w", classify(err))
 	}
 
+	return &transaction{tx}, nil
+}
+
+func (te *transExec) WithTx(ctx context.Context, fn func(tx Tx) error) error {
 	var err error
-	te.tx, err = te.connection.Begin(context.Background())
-	if err != nil {
-		return fmt.Errorf("cannot start transaction; err: %w", err)
+
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = te.runTx(ctx, fn)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("transaction was not retried; err: %w", ctx.Err())
+		case <-time.After(time.Duration(attempt) * txRetryBackoff):
+		}
 	}
 
-	te.isTxBegun = true
-	return nil
+	return fmt.Errorf("transaction failed after %v attempts; err: %w", maxTxAttempts, err)
 }
 
-func (te *transExec) Commit() error {
-	if !te.isTxBegun {
-		return nil
+func (te *transExec) runTx(ctx context.Context, fn func(tx Tx) error) (err error) {
+	tx, err := te.Begin(ctx)
+	if err != nil {
+		return err
 	}
 
-	te.isTxBegun = false
-	return te.tx.Commit(context.Background())
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		rollbackErr := tx.Rollback(ctx)
+		if rollbackErr != nil {
+			return fmt.Errorf("%w; cannot rollback transaction: %v", err, rollbackErr)
+		}
+
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (te *transExec) Rollback() error {
-	if !te.isTxBegun {
-		return nil
+// isRetryable reports whether the transaction failed because of a conflict with
+// a concurrent one and may succeed when run again.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
 	}
 
-	te.isTxBegun = false
-	return te.tx.Rollback(context.Background())
+	return pgErr.Code == "40001" || pgErr.Code == "40P01" // serialization_failure, deadlock_detected
+}
+
+func (t *transaction) Commit(ctx context.Context) error {
+	return classify(t.tx.Commit(ctx))
 }
 
-func (te *transExec) Exec(query string, args ...interface{}) error {
-	_, err := te.tx.Exec(context.Background(), query, args...)
-	return err
+func (t *transaction) Rollback(ctx context.Context) error {
+	err := t.tx.Rollback(ctx)
+	if errors.Is(err, pgx.ErrTxClosed) {
+		return nil
+	}
+
+	return classify(err)
 }
 
-func (te *transExec) Query(query string, args ...interface{}) (pgx.Rows, error) {
-	return te.tx.Query(context.Background(), query, args...)
+func (t *transaction) Exec(ctx context.Context, query string, args ...interface{}) error {
+	_, err := t.tx.Exec(ctx, query, args...)
+	return classify(err)
 }
 
-func (te *transExec) LockMoney() error {
-	err := te.Begin()
+func (t *transaction) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := t.tx.Query(ctx, query, args...)
 	if err != nil {
-		return err
+		return nil, classify(err)
 	}
 
-	return te.Exec("LOCK TABLE selling IN ACCESS EXCLUSIVE MODE")
+	return classifiedRows{rows}, nil
+}
+
+func (t *transaction) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return classifiedRow{t.tx.QueryRow(ctx, query, args...)}
+}
+
+func (t *transaction) LockMoney(ctx context.Context) error {
+	return t.Exec(ctx, "LOCK TABLE selling IN ACCESS EXCLUSIVE MODE")
 }
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestVerifyCredentials(t *testing.T) {
+	pc, _ := newTestClient(t)
+	ctx := context.Background()
+
+	email := fmt.Sprintf("%v-%d@test", t.Name(), testRandInt(1_000_000_000))
+
+	// a user from before passwords were hashed
+	err := pc.exec(ctx, `INSERT INTO users (email, pass) VALUES ($1, 'secret')`, email)
+	if err != nil {
+		t.Fatalf("cannot add user %v; err: %v", email, err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		email    string
+		password string
+	}{
+		{"unknown email", "unknown-" + email, "secret"},
+		{"wrong password", email, "wrong"},
+		{"password prefix", email, "secre"},
+	} {
+		_, err = pc.VerifyCredentials(ctx, tt.email, tt.password)
+		if err != ErrInvalidCredentials {
+			t.Errorf("%v: VerifyCredentials() err = %v, want bare %v", tt.name, err, ErrInvalidCredentials)
+		}
+	}
+
+	id, err := pc.VerifyCredentials(ctx, email, "secret")
+	if err != nil {
+		t.Fatalf("VerifyCredentials() err = %v", err)
+	}
+
+	hash, hashed := "", false
+	err = pc.queryRow(ctx, `SELECT pass, pass_hashed FROM users WHERE id = $1`, id).Scan(&hash, &hashed)
+	if err != nil {
+		t.Fatalf("cannot get the password of the user (id = %v); err: %v", id, err)
+	}
+
+	if !hashed || !strings.HasPrefix(hash, argon2idPrefix) {
+		t.Fatalf("plaintext password was not rehashed on sign in: %q, hashed = %v", hash, hashed)
+	}
+
+	if _, err = pc.VerifyCredentials(ctx, email, "secret"); err != nil {
+		t.Errorf("VerifyCredentials() after the rehash err = %v", err)
+	}
+
+	if _, err = pc.VerifyCredentials(ctx, email, hash); err != ErrInvalidCredentials {
+		t.Errorf("VerifyCredentials() with the hash as the password err = %v, want %v", err, ErrInvalidCredentials)
+	}
+}
+
+func TestHashPlaintextPasswords(t *testing.T) {
+	pc, _ := newTestClient(t)
+	ctx := context.Background()
+
+	if _, err := pc.HashPlaintextPasswords(ctx, 0); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("HashPlaintextPasswords() with a zero batch err = %v, want %v", err, ErrInvalidAmount)
+	}
+
+	emails := make([]string, 3)
+	for i := range emails {
+		emails[i] = fmt.Sprintf("%v-%d@test", t.Name(), testRandInt(1_000_000_000))
+
+		err := pc.exec(ctx, `INSERT INTO users (email, pass) VALUES ($1, 'secret')`, emails[i])
+		if err != nil {
+			t.Fatalf("cannot add user %v; err: %v", emails[i], err)
+		}
+	}
+
+	// batches smaller than the number of plaintext passwords
+	hashed, err := pc.HashPlaintextPasswords(ctx, 2)
+	if err != nil {
+		t.Fatalf("HashPlaintextPasswords() err = %v", err)
+	}
+
+	if hashed < len(emails) {
+		t.Errorf("HashPlaintextPasswords() = %v, want at least %v", hashed, len(emails))
+	}
+
+	plaintext := 0
+	err = pc.queryRow(ctx, `SELECT count(*) FROM users WHERE NOT pass_hashed`).Scan(&plaintext)
+	if err != nil {
+		t.Fatalf("cannot count plaintext passwords; err: %v", err)
+	}
+
+	if plaintext != 0 {
+		t.Errorf("%v plaintext passwords left", plaintext)
+	}
+
+	for _, email := range emails {
+		if _, err = pc.VerifyCredentials(ctx, email, "secret"); err != nil {
+			t.Errorf("VerifyCredentials(%v) after hashing err = %v", email, err)
+		}
+	}
+
+	// an empty hash locks the user out
+	locked := testUser(t, pc)
+	email := ""
+
+	err = pc.queryRow(ctx, `SELECT email FROM users WHERE id = $1`, locked).Scan(&email)
+	if err != nil {
+		t.Fatalf("cannot get the email of the user (id = %v); err: %v", locked, err)
+	}
+
+	if _, err = pc.VerifyCredentials(ctx, email, ""); err != ErrInvalidCredentials {
+		t.Errorf("VerifyCredentials() of a locked user err = %v, want %v", err, ErrInvalidCredentials)
+	}
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Content types an envelope can be encoded with.
+const (
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeJSON     = "application/json"
+)
+
+// versioned is implemented by payloads whose schema changed since the first version.
+type versioned interface {
+	SchemaVersion() uint32
+}
+
+// TypeOf returns the envelope type of the payload, e.g. "events.PriceChanged".
+func TypeOf(payload proto.Message) string {
+	return string(proto.MessageName(payload))
+}
+
+// Wrap puts payload in a new envelope with a random ID and the current time.
+func Wrap(payload proto.Message, correlationID string) (*Envelope, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	return WrapWithID(payload, id, correlationID)
+}
+
+// WrapWithID is Wrap with an ID chosen by the caller, e.g. one derived from the operation
+// that caused the event, so the same event always gets the same ID.
+func WrapWithID(payload proto.Message, id, correlationID string) (*Envelope, error) {
+	if id == "" {
+		return nil, fmt.Errorf("cannot wrap %v: empty id", TypeOf(payload))
+	}
+
+	packed, err := anypb.New(payload)
+	if err != nil {
+		return nil, fmt.Errorf("cannot pack %v; err: %w", TypeOf(payload), err)
+	}
+
+	version := uint32(1)
+	if v, ok := payload.(versioned); ok {
+		version = v.SchemaVersion()
+	}
+
+	return &Envelope{
+		Id:            id,
+		Type:          TypeOf(payload),
+		Version:       version,
+		CorrelationID: correlationID,
+		Timestamp:     timestamppb.Now(),
+		Payload:       packed,
+	}, nil
+}
+
+// Unwrap returns the payload as a message of its own type. The type must be linked into the binary.
+func (e *Envelope) Unwrap() (proto.Message, error) {
+	payload, err := e.GetPayload().UnmarshalNew()
+	if err != nil {
+		return nil, fmt.Errorf("cannot unpack %v payload of the message %v; err: %w", e.GetType(), e.GetId(), err)
+	}
+
+	return payload, nil
+}
+
+func Encode(e *Envelope, contentType string) ([]byte, error) {
+	switch contentType {
+	case ContentTypeProtobuf:
+		return proto.Marshal(e)
+	case ContentTypeJSON:
+		return protojson.Marshal(e)
+	}
+
+	return nil, fmt.Errorf("unknown content type %q", contentType)
+}
+
+func Decode(data []byte, contentType string) (*Envelope, error) {
+	e := &Envelope{}
+
+	var err error
+	switch contentType {
+	case ContentTypeProtobuf:
+		err = proto.Unmarshal(data, e)
+	case ContentTypeJSON:
+		err = protojson.Unmarshal(data, e)
+	default:
+		return nil, fmt.Errorf("unknown content type %q", contentType)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode %v envelope; err: %w", contentType, err)
+	}
+
+	return e, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate a message id; err: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,24 @@
+package events
+
+import "google.golang.org/protobuf/proto"
+
+// RoutingKey returns the topic exchange routing key of the payload: "price.<currency>" for PriceChanged,
+// "trade.<currency>.<paidCurrency>" for TradeExecuted and the payload type for anything else.
+func RoutingKey(payload proto.Message) string {
+	switch p := payload.(type) {
+	case *PriceChanged:
+		return PriceRoutingKey(p.Currency)
+	case *TradeExecuted:
+		return TradeRoutingKey(p.Currency, p.PaidCurrency)
+	}
+
+	return TypeOf(payload)
+}
+
+func PriceRoutingKey(currency string) string {
+	return "price." + currency
+}
+
+func TradeRoutingKey(currency, paidCurrency string) string {
+	return "trade." + currency + "." + paidCurrency
+}
@@ -8,6 +8,10 @@ protoc --go_out=serverHandler/ --go_opt=paths=source_relative \
 --go-grpc_opt=paths=source_relative \
 server_handler.proto
 
+mkdir events
+protoc --go_out=events/ --go_opt=paths=source_relative \
+events.proto
+
 #frontend
 curl -sSL https://github.com/grpc/grpc-web/releases/download/1.3.1/protoc-gen-grpc-web-1.3.1-linux-x86_64 \
  /usr/local/bin/protoc-gen-grpc-web
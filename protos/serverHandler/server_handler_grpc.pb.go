@@ -27,9 +27,13 @@ type DashboardServiceClient interface {
 	GetAllCurrencies(ctx context.Context, in *EmptyMsg, opts ...grpc.CallOption) (*GetCurrenciesResponse, error)
 	BuyCurrency(ctx context.Context, in *SellOperation, opts ...grpc.CallOption) (*DefaultStringMsg, error)
 	SellCurrency(ctx context.Context, in *SellOperation, opts ...grpc.CallOption) (*DefaultStringMsg, error)
+	// Deprecated: Do not use.
+	// Deprecated: floats lose precision, use GetCurrencyDecimalValue.
 	GetCurrencyValue(ctx context.Context, in *DefaultStringMsg, opts ...grpc.CallOption) (DashboardService_GetCurrencyValueClient, error)
+	GetCurrencyDecimalValue(ctx context.Context, in *DefaultStringMsg, opts ...grpc.CallOption) (DashboardService_GetCurrencyDecimalValueClient, error)
 	GetUserMoney(ctx context.Context, in *EmptyMsg, opts ...grpc.CallOption) (*GetCurrenciesResponse, error)
-	GetUserHistory(ctx context.Context, in *EmptyMsg, opts ...grpc.CallOption) (*GetUserHistoryResponse, error)
+	GetUserHistory(ctx context.Context, in *GetUserHistoryRequest, opts ...grpc.CallOption) (*GetUserHistoryResponse, error)
+	GetCandles(ctx context.Context, in *GetCandlesRequest, opts ...grpc.CallOption) (*GetCandlesResponse, error)
 }
 
 type dashboardServiceClient struct {
@@ -85,6 +89,7 @@ func (c *dashboardServiceClient) SellCurrency(ctx context.Context, in *SellOpera
 	return out, nil
 }
 
+// Deprecated: Do not use.
 func (c *dashboardServiceClient) GetCurrencyValue(ctx context.Context, in *DefaultStringMsg, opts ...grpc.CallOption) (DashboardService_GetCurrencyValueClient, error) {
 	stream, err := c.cc.NewStream(ctx, &DashboardService_ServiceDesc.Streams[0], "/serverHandler.DashboardService/GetCurrencyValue", opts...)
 	if err != nil {
@@ -117,6 +122,38 @@ func (x *dashboardServiceGetCurrencyValueClient) Recv() (*DefaultFloatMsg, error
 	return m, nil
 }
 
+func (c *dashboardServiceClient) GetCurrencyDecimalValue(ctx context.Context, in *DefaultStringMsg, opts ...grpc.CallOption) (DashboardService_GetCurrencyDecimalValueClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DashboardService_ServiceDesc.Streams[1], "/serverHandler.DashboardService/GetCurrencyDecimalValue", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dashboardServiceGetCurrencyDecimalValueClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DashboardService_GetCurrencyDecimalValueClient interface {
+	Recv() (*DefaultDecimalMsg, error)
+	grpc.ClientStream
+}
+
+type dashboardServiceGetCurrencyDecimalValueClient struct {
+	grpc.ClientStream
+}
+
+func (x *dashboardServiceGetCurrencyDecimalValueClient) Recv() (*DefaultDecimalMsg, error) {
+	m := new(DefaultDecimalMsg)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *dashboardServiceClient) GetUserMoney(ctx context.Context, in *EmptyMsg, opts ...grpc.CallOption) (*GetCurrenciesResponse, error) {
 	out := new(GetCurrenciesResponse)
 	err := c.cc.Invoke(ctx, "/serverHandler.DashboardService/GetUserMoney", in, out, opts...)
@@ -126,7 +163,7 @@ func (c *dashboardServiceClient) GetUserMoney(ctx context.Context, in *EmptyMsg,
 	return out, nil
 }
 
-func (c *dashboardServiceClient) GetUserHistory(ctx context.Context, in *EmptyMsg, opts ...grpc.CallOption) (*GetUserHistoryResponse, error) {
+func (c *dashboardServiceClient) GetUserHistory(ctx context.Context, in *GetUserHistoryRequest, opts ...grpc.CallOption) (*GetUserHistoryResponse, error) {
 	out := new(GetUserHistoryResponse)
 	err := c.cc.Invoke(ctx, "/serverHandler.DashboardService/GetUserHistory", in, out, opts...)
 	if err != nil {
@@ -135,6 +172,15 @@ func (c *dashboardServiceClient) GetUserHistory(ctx context.Context, in *EmptyMs
 	return out, nil
 }
 
+func (c *dashboardServiceClient) GetCandles(ctx context.Context, in *GetCandlesRequest, opts ...grpc.CallOption) (*GetCandlesResponse, error) {
+	out := new(GetCandlesResponse)
+	err := c.cc.Invoke(ctx, "/serverHandler.DashboardService/GetCandles", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DashboardServiceServer is the server API for DashboardService service.
 // All implementations must embed UnimplementedDashboardServiceServer
 // for forward compatibility
@@ -144,9 +190,13 @@ type DashboardServiceServer interface {
 	GetAllCurrencies(context.Context, *EmptyMsg) (*GetCurrenciesResponse, error)
 	BuyCurrency(context.Context, *SellOperation) (*DefaultStringMsg, error)
 	SellCurrency(context.Context, *SellOperation) (*DefaultStringMsg, error)
+	// Deprecated: Do not use.
+	// Deprecated: floats lose precision, use GetCurrencyDecimalValue.
 	GetCurrencyValue(*DefaultStringMsg, DashboardService_GetCurrencyValueServer) error
+	GetCurrencyDecimalValue(*DefaultStringMsg, DashboardService_GetCurrencyDecimalValueServer) error
 	GetUserMoney(context.Context, *EmptyMsg) (*GetCurrenciesResponse, error)
-	GetUserHistory(context.Context, *EmptyMsg) (*GetUserHistoryResponse, error)
+	GetUserHistory(context.Context, *GetUserHistoryRequest) (*GetUserHistoryResponse, error)
+	GetCandles(context.Context, *GetCandlesRequest) (*GetCandlesResponse, error)
 	mustEmbedUnimplementedDashboardServiceServer()
 }
 
@@ -172,12 +222,18 @@ func (UnimplementedDashboardServiceServer) SellCurrency(context.Context, *SellOp
 func (UnimplementedDashboardServiceServer) GetCurrencyValue(*DefaultStringMsg, DashboardService_GetCurrencyValueServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetCurrencyValue not implemented")
 }
+func (UnimplementedDashboardServiceServer) GetCurrencyDecimalValue(*DefaultStringMsg, DashboardService_GetCurrencyDecimalValueServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetCurrencyDecimalValue not implemented")
+}
 func (UnimplementedDashboardServiceServer) GetUserMoney(context.Context, *EmptyMsg) (*GetCurrenciesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserMoney not implemented")
 }
-func (UnimplementedDashboardServiceServer) GetUserHistory(context.Context, *EmptyMsg) (*GetUserHistoryResponse, error) {
+func (UnimplementedDashboardServiceServer) GetUserHistory(context.Context, *GetUserHistoryRequest) (*GetUserHistoryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserHistory not implemented")
 }
+func (UnimplementedDashboardServiceServer) GetCandles(context.Context, *GetCandlesRequest) (*GetCandlesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCandles not implemented")
+}
 func (UnimplementedDashboardServiceServer) mustEmbedUnimplementedDashboardServiceServer() {}
 
 // UnsafeDashboardServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -302,6 +358,27 @@ func (x *dashboardServiceGetCurrencyValueServer) Send(m *DefaultFloatMsg) error
 	return x.ServerStream.SendMsg(m)
 }
 
+func _DashboardService_GetCurrencyDecimalValue_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DefaultStringMsg)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DashboardServiceServer).GetCurrencyDecimalValue(m, &dashboardServiceGetCurrencyDecimalValueServer{stream})
+}
+
+type DashboardService_GetCurrencyDecimalValueServer interface {
+	Send(*DefaultDecimalMsg) error
+	grpc.ServerStream
+}
+
+type dashboardServiceGetCurrencyDecimalValueServer struct {
+	grpc.ServerStream
+}
+
+func (x *dashboardServiceGetCurrencyDecimalValueServer) Send(m *DefaultDecimalMsg) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _DashboardService_GetUserMoney_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(EmptyMsg)
 	if err := dec(in); err != nil {
@@ -321,7 +398,7 @@ func _DashboardService_GetUserMoney_Handler(srv interface{}, ctx context.Context
 }
 
 func _DashboardService_GetUserHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(EmptyMsg)
+	in := new(GetUserHistoryRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -333,7 +410,25 @@ func _DashboardService_GetUserHistory_Handler(srv interface{}, ctx context.Conte
 		FullMethod: "/serverHandler.DashboardService/GetUserHistory",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(DashboardServiceServer).GetUserHistory(ctx, req.(*EmptyMsg))
+		return srv.(DashboardServiceServer).GetUserHistory(ctx, req.(*GetUserHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DashboardService_GetCandles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCandlesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DashboardServiceServer).GetCandles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/serverHandler.DashboardService/GetCandles",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DashboardServiceServer).GetCandles(ctx, req.(*GetCandlesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -373,6 +468,10 @@ var DashboardService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetUserHistory",
 			Handler:    _DashboardService_GetUserHistory_Handler,
 		},
+		{
+			MethodName: "GetCandles",
+			Handler:    _DashboardService_GetCandles_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -380,6 +479,11 @@ var DashboardService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _DashboardService_GetCurrencyValue_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetCurrencyDecimalValue",
+			Handler:       _DashboardService_GetCurrencyDecimalValue_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "server_handler.proto",
 }
@@ -0,0 +1,282 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+// CandleInterval is the length of a candle.
+type CandleInterval string
+
+const (
+	CandleInterval1m CandleInterval = "1m"
+	CandleInterval5m CandleInterval = "5m"
+	CandleInterval1h CandleInterval = "1h"
+	CandleInterval1d CandleInterval = "1d"
+)
+
+// Prices and amounts are NUMERIC(20, 8) in postgres. Candles keep them in redis as integers of 1e-8 units
+// written as decimal digits, which lua compares and adds as strings: its numbers are doubles that cannot
+// hold such integers exactly. Prices are zero-padded to priceDigits, so comparing them as strings compares
+// their values; volumes have as many digits as they need.
+const (
+	unitScale   = 8
+	priceDigits = 20
+)
+
+var candleIntervals = []struct {
+	interval  CandleInterval
+	length    time.Duration
+	retention time.Duration // zero keeps candles forever
+}{
+	{CandleInterval1m, time.Minute, 2 * 24 * time.Hour},
+	{CandleInterval5m, 5 * time.Minute, 7 * 24 * time.Hour},
+	{CandleInterval1h, time.Hour, 90 * 24 * time.Hour},
+	{CandleInterval1d, 24 * time.Hour, 0},
+}
+
+// Candle is open-high-low-close-volume of the trades within [Start, Start + interval).
+type Candle struct {
+	Start  time.Time
+	Open   decimal.Decimal
+	High   decimal.Decimal
+	Low    decimal.Decimal
+	Close  decimal.Decimal
+	Volume decimal.Decimal
+}
+
+// updateCandles applies a trade to the candle of every interval. Candle hashes keep the times of their
+// opening and closing trades, so trades that come out of order still give the right open and close.
+//
+// KEYS: index and hash key of every interval.
+// ARGV: padded price and volume in units, trade time in ms, then start (s) and TTL (s, 0 for none) of every interval.
+var updateCandles = redis.NewScript(`
+local price, volume, at = ARGV[1], ARGV[2], tonumber(ARGV[3])
+
+-- sum of two non-negative integers written as decimal digits
+local function add(a, b)
+	local digits, carry = {}, 0
+	local i, j = #a, #b
+
+	while i > 0 or j > 0 or carry > 0 do
+		local sum = carry
+
+		if i > 0 then
+			sum = sum + a:byte(i) - 48
+			i = i - 1
+		end
+
+		if j > 0 then
+			sum = sum + b:byte(j) - 48
+			j = j - 1
+		end
+
+		digits[#digits + 1] = sum % 10
+		carry = math.floor(sum / 10)
+	end
+
+	return string.reverse(table.concat(digits))
+end
+
+for i = 1, #KEYS / 2 do
+	local index, candle = KEYS[2 * i - 1], KEYS[2 * i]
+	local start, ttl = ARGV[2 + 2 * i], tonumber(ARGV[3 + 2 * i])
+
+	if redis.call('EXISTS', candle) == 0 then
+		redis.call('HSET', candle, 'open', price, 'high', price, 'low', price, 'close', price,
+			'volume', volume, 'open_at', at, 'close_at', at)
+	else
+		local fields = redis.call('HMGET', candle, 'high', 'low', 'open_at', 'close_at', 'volume')
+
+		if price > fields[1] then
+			redis.call('HSET', candle, 'high', price)
+		end
+
+		if price < fields[2] then
+			redis.call('HSET', candle, 'low', price)
+		end
+
+		if at < tonumber(fields[3]) then
+			redis.call('HSET', candle, 'open', price, 'open_at', at)
+		end
+
+		if at >= tonumber(fields[4]) then
+			redis.call('HSET', candle, 'close', price, 'close_at', at)
+		end
+
+		redis.call('HSET', candle, 'volume', add(fields[5], volume))
+	end
+
+	redis.call('ZADD', index, start, start)
+
+	if ttl > 0 then
+		redis.call('EXPIRE', candle, ttl)
+		redis.call('ZREMRANGEBYSCORE', index, '-inf', '(' .. (tonumber(start) - ttl))
+	end
+end
+
+return #KEYS / 2
+`)
+
+// RecordTrade adds a trade of amount of the currency at price to the candles of every interval.
+// Price and amount are truncated to 8 decimal places and must stay positive.
+func (rc *redisClient) RecordTrade(currency string, price, amount decimal.Decimal, at time.Time) error {
+	priceUnits := price.Shift(unitScale).Truncate(0)
+	amountUnits := amount.Shift(unitScale).Truncate(0)
+
+	if !priceUnits.IsPositive() || len(priceUnits.String()) > priceDigits {
+		return fmt.Errorf("cannot add the trade of %v %v at %v to candles: invalid price", amount, currency, price)
+	}
+
+	if !amountUnits.IsPositive() {
+		return fmt.Errorf("cannot add the trade of %v %v at %v to candles: invalid amount", amount, currency, price)
+	}
+
+	keys := make([]string, 0, 2*len(candleIntervals))
+	args := []interface{}{
+		fmt.Sprintf("%0*s", priceDigits, priceUnits.String()),
+		amountUnits.String(),
+		at.UnixMilli(),
+	}
+
+	for _, ci := range candleIntervals {
+		start := at.Truncate(ci.length).Unix()
+
+		keys = append(keys, candleIndexKey(currency, ci.interval), candleKey(currency, ci.interval, start))
+		args = append(args, start, int64(ci.retention.Seconds()))
+	}
+
+	err := updateCandles.Run(context.Background(), rc.client, keys, args...).Err()
+	if err != nil {
+		return fmt.Errorf("cannot add the trade of %v %v at %v to candles; err: %w", amount, currency, price, classify(err))
+	}
+
+	return nil
+}
+
+// GetCandles returns candles of the currency that start within [from, to], oldest first.
+// Zero from and to mean no bound. Intervals without trades have no candles.
+func (rc *redisClient) GetCandles(currency string, interval CandleInterval, from, to time.Time) ([]*Candle, error) {
+	if interval.length() == 0 {
+		return nil, fmt.Errorf("unknown candle interval %q", interval)
+	}
+
+	starts, err := rc.client.ZRangeByScore(context.Background(), candleIndexKey(currency, interval), &redis.ZRangeBy{
+		Min: unixBound(from, "-inf"),
+		Max: unixBound(to, "+inf"),
+	}).Result()
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot get %v candles of the currency %v; err: %w", interval, currency, classify(err))
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, 0, len(starts))
+
+	_, err = rc.client.Pipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		for _, start := range starts {
+			cmds = append(cmds, pipe.HGetAll(context.Background(), candleIndexKey(currency, interval)+":"+start))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot get %v candles of the currency %v; err: %w", interval, currency, classify(err))
+	}
+
+	candles := make([]*Candle, 0, len(starts))
+
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			continue // expired, the index is trimmed on the next trade
+		}
+
+		candle, err := decodeCandle(starts[i], fields)
+		if err != nil {
+			return nil, err
+		}
+
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+func (i CandleInterval) length() time.Duration {
+	for _, ci := range candleIntervals {
+		if ci.interval == i {
+			return ci.length
+		}
+	}
+
+	return 0
+}
+
+func decodeCandle(start string, fields map[string]string) (*Candle, error) {
+	startUnix, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid candle start %q; err: %w", start, err)
+	}
+
+	candle := &Candle{Start: time.Unix(startUnix, 0).UTC()}
+
+	prices := []struct {
+		field string
+		value *decimal.Decimal
+	}{
+		{"open", &candle.Open},
+		{"high", &candle.High},
+		{"low", &candle.Low},
+		{"close", &candle.Close},
+	}
+
+	for _, p := range prices {
+		*p.value, err = decodeUnits(fields[p.field])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v of the candle %v; err: %w", p.field, start, err)
+		}
+	}
+
+	candle.Volume, err = decodeUnits(fields["volume"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid volume of the candle %v; err: %w", start, err)
+	}
+
+	return candle, nil
+}
+
+// decodeUnits parses an integer of 1e-8 units, padded or not.
+func decodeUnits(units string) (decimal.Decimal, error) {
+	value, err := decimal.NewFromString(units)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	if !value.Equal(value.Truncate(0)) {
+		return decimal.Zero, fmt.Errorf("%q is not an integer", units)
+	}
+
+	return value.Shift(-unitScale), nil
+}
+
+func candleIndexKey(currency string, interval CandleInterval) string {
+	return currency + RedisCurrencyOHLCVSuffix + "_" + string(interval)
+}
+
+func candleKey(currency string, interval CandleInterval, start int64) string {
+	return candleIndexKey(currency, interval) + ":" + strconv.FormatInt(start, 10)
+}
+
+func unixBound(t time.Time, unbounded string) string {
+	if t.IsZero() {
+		return unbounded
+	}
+
+	return strconv.FormatInt(t.Unix(), 10)
+}
@@ -1,5 +1,14 @@
 package redis
 
-const RedisCurrencyOperationsSuffix = "_operations" // number of operation that were processed with current currency
-const RedisCurrencyPriceSuffix = "_price" // list of the prices that were used to sold current currency
-const UserTokenSuffix = "_expiresAt"
+// Keys of earlier versions, which kept lists of the prices under both of them.
+//
+// Deprecated: they are no longer read or written.
+const (
+	RedisCurrencyOperationsSuffix = "_operations"
+	RedisCurrencyPriceSuffix      = "_price"
+)
+
+const RedisCurrencyOperationsCountSuffix = "_operations_count" // number of operation that were processed with current currency
+const RedisCurrencyPriceSeriesSuffix = "_price_series"         // sorted set of the prices that were used to sold current currency, scored by unix milliseconds
+const RedisCurrencyOHLCVSuffix = "_ohlcv"                      // <currency>_ohlcv_<interval> is a sorted set of candle starts; <currency>_ohlcv_<interval>:<start> is the candle hash
+const RedisCurrencyPriceStreamSuffix = "_prices"               // stream of the price ticks of current currency
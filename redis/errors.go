@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Kana-v1-exchange/enviroment/internal/errkind"
+	"github.com/go-redis/redis/v9"
+)
+
+// Errors returned by the package. Check them with errors.Is; the client error stays in the chain.
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrConnectionLost = errors.New("connection to the redis server is lost")
+)
+
+// isAuthError reports whether the server rejected the credentials, which retrying does not fix.
+func isAuthError(err error) bool {
+	var redisErr redis.Error
+	if !errors.As(err, &redisErr) {
+		return false
+	}
+
+	msg := redisErr.Error()
+	return strings.HasPrefix(msg, "WRONGPASS") || strings.HasPrefix(msg, "NOAUTH")
+}
+
+// classify wraps a client error so that it matches the package error it stands for.
+// Errors that do not stand for any are returned as is.
+func classify(err error) error {
+	return errkind.Classify(err, kindOf)
+}
+
+// kindOf returns the package error a client error stands for, or nil.
+func kindOf(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return ErrNotFound
+	}
+
+	if errkind.IsNetwork(err) || errors.Is(err, redis.ErrClosed) {
+		return ErrConnectionLost
+	}
+
+	return nil
+}
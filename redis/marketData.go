@@ -0,0 +1,139 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+const defaultPriceRetention = 24 * time.Hour
+
+// PricePoint is a price the currency was traded at.
+type PricePoint struct {
+	Time  time.Time
+	Price decimal.Decimal
+}
+
+// AddOperation counts an operation with the currency, adds its price to the price series and publishes it
+// to the price stream. Prices older than RedisSettings.PriceRetention are trimmed. The commands run in one
+// MULTI/EXEC, so no client sees a part of them, but Redis does not roll back: if a command fails, the others
+// are still applied and the error of the first failed one is returned.
+func (rc *redisClient) AddOperation(currency string, price decimal.Decimal) error {
+	now := time.Now()
+
+	_, err := rc.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Incr(context.Background(), currency+RedisCurrencyOperationsCountSuffix)
+		pipe.ZAdd(context.Background(), currency+RedisCurrencyPriceSeriesSuffix, redis.Z{
+			Score:  float64(now.UnixMilli()),
+			Member: encodePricePoint(now, price),
+		})
+		pipe.ZRemRangeByScore(
+			context.Background(),
+			currency+RedisCurrencyPriceSeriesSuffix,
+			"-inf",
+			"("+strconv.FormatInt(now.Add(-rc.priceRetention).UnixMilli(), 10),
+		)
+		pipe.XAdd(context.Background(), rc.priceTickArgs(currency, price, now))
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("cannot insert price (%v) of the currency(%v); err: %w", price, currency, classify(err))
+	}
+
+	return nil
+}
+
+// GetOperationsCount returns the number of operations with the currency; zero if there were none.
+func (rc *redisClient) GetOperationsCount(currency string) (int64, error) {
+	count, err := rc.client.Get(context.Background(), currency+RedisCurrencyOperationsCountSuffix).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("cannot get the number of operations with the currency %v; err: %w", currency, classify(err))
+	}
+
+	return count, nil
+}
+
+// GetPrices returns prices of the currency from the [from, to] range, oldest first.
+// Zero from and to mean the start and the end of the series.
+func (rc *redisClient) GetPrices(currency string, from, to time.Time) ([]*PricePoint, error) {
+	members, err := rc.client.ZRangeByScore(context.Background(), currency+RedisCurrencyPriceSeriesSuffix, &redis.ZRangeBy{
+		Min: scoreBound(from, "-inf"),
+		Max: scoreBound(to, "+inf"),
+	}).Result()
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot get prices of the currency %v; err: %w", currency, classify(err))
+	}
+
+	return decodePricePoints(members)
+}
+
+// GetLastPrice returns the latest price of the currency or ErrNotFound if it was never traded.
+func (rc *redisClient) GetLastPrice(currency string) (*PricePoint, error) {
+	members, err := rc.client.ZRange(context.Background(), currency+RedisCurrencyPriceSeriesSuffix, -1, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the last price of the currency %v; err: %w", currency, classify(err))
+	}
+
+	if len(members) == 0 {
+		return nil, fmt.Errorf("%w; currency %v has no prices", ErrNotFound, currency)
+	}
+
+	points, err := decodePricePoints(members)
+	if err != nil {
+		return nil, err
+	}
+
+	return points[0], nil
+}
+
+// Members of the price series are "<unix nano>:<price>": the time keeps equal prices apart.
+
+func encodePricePoint(t time.Time, price decimal.Decimal) string {
+	return strconv.FormatInt(t.UnixNano(), 10) + ":" + price.String()
+}
+
+func decodePricePoints(members []string) ([]*PricePoint, error) {
+	res := make([]*PricePoint, 0, len(members))
+
+	for _, member := range members {
+		parts := strings.SplitN(member, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid price point %q", member)
+		}
+
+		nanos, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time of the price point %q; err: %w", member, err)
+		}
+
+		price, err := decimal.NewFromString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid price of the price point %q; err: %w", member, err)
+		}
+
+		res = append(res, &PricePoint{Time: time.Unix(0, nanos), Price: price})
+	}
+
+	return res, nil
+}
+
+func scoreBound(t time.Time, unbounded string) string {
+	if t.IsZero() {
+		return unbounded
+	}
+
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultPriceStreamLength = 10_000
+
+	priceFeedBlock      = 5 * time.Second // XREAD returns after this long without ticks, so cancelling is noticed
+	priceFeedBatch      = 100
+	priceFeedRetryDelay = time.Second
+)
+
+// PriceTick is an entry of the price stream of a currency. Pass ID to SubscribePrices to resume after it.
+type PriceTick struct {
+	ID    string
+	Time  time.Time
+	Price decimal.Decimal
+}
+
+// PublishPrice appends a price tick to the stream of the currency and returns the tick ID.
+// The stream keeps about RedisSettings.PriceStreamLength latest ticks.
+func (rc *redisClient) PublishPrice(currency string, price decimal.Decimal) (string, error) {
+	id, err := rc.client.XAdd(context.Background(), rc.priceTickArgs(currency, price, time.Now())).Result()
+	if err != nil {
+		return "", fmt.Errorf("cannot publish price (%v) of the currency %v; err: %w", price, currency, classify(err))
+	}
+
+	return id, nil
+}
+
+// SubscribePrices streams price ticks of the currency that come after fromID, or only new ones
+// if fromID is empty. Connection errors are retried from the last received tick, so no tick is missed
+// as long as it is still in the stream. The channel is closed when ctx is done.
+// Every subscriber holds a connection of the pool while it waits for ticks.
+func (rc *redisClient) SubscribePrices(ctx context.Context, currency string, fromID string) (<-chan *PriceTick, error) {
+	stream := currency + RedisCurrencyPriceStreamSuffix
+
+	lastID := fromID
+	if lastID == "" {
+		// "$" would skip ticks added between two reads, so resume from the current last tick instead
+		last, err := rc.client.XRevRangeN(ctx, stream, "+", "-", 1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("cannot subscribe to prices of the currency %v; err: %w", currency, classify(err))
+		}
+
+		lastID = "0-0"
+		if len(last) > 0 {
+			lastID = last[0].ID
+		}
+	}
+
+	ticks := make(chan *PriceTick)
+
+	go func() {
+		defer close(ticks)
+
+		for ctx.Err() == nil {
+			streams, err := rc.client.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{stream, lastID},
+				Count:   priceFeedBatch,
+				Block:   priceFeedBlock,
+			}).Result()
+
+			if err != nil {
+				if errors.Is(err, redis.Nil) {
+					continue
+				}
+
+				select {
+				case <-ctx.Done():
+				case <-time.After(priceFeedRetryDelay):
+				}
+
+				continue
+			}
+
+			for _, s := range streams {
+				for _, msg := range s.Messages {
+					tick, err := decodePriceTick(msg)
+					if err != nil {
+						lastID = msg.ID
+						continue
+					}
+
+					select {
+					case ticks <- tick:
+						lastID = msg.ID
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return ticks, nil
+}
+
+func (rc *redisClient) priceTickArgs(currency string, price decimal.Decimal, at time.Time) *redis.XAddArgs {
+	return &redis.XAddArgs{
+		Stream: currency + RedisCurrencyPriceStreamSuffix,
+		MaxLen: rc.priceStreamLength,
+		Approx: true,
+		Values: []interface{}{"price", price.String(), "time", at.UnixNano()},
+	}
+}
+
+func decodePriceTick(msg redis.XMessage) (*PriceTick, error) {
+	priceStr, _ := msg.Values["price"].(string)
+	price, err := decimal.NewFromString(priceStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price of the tick %v; err: %w", msg.ID, err)
+	}
+
+	tick := &PriceTick{ID: msg.ID, Price: price}
+
+	timeStr, _ := msg.Values["time"].(string)
+	nanos, err := strconv.ParseInt(timeStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time of the tick %v; err: %w", msg.ID, err)
+	}
+
+	tick.Time = time.Unix(0, nanos)
+
+	return tick, nil
+}
@@ -2,17 +2,22 @@ package redis
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Kana-v1-exchange/enviroment/retry"
 	"github.com/go-redis/redis/v9"
+	"github.com/shopspring/decimal"
 )
 
 type RedisSettings struct {
 	Host     string
 	Port     string
 	Password string
+
+	PriceRetention    time.Duration // how long AddOperation keeps prices; zero means 24h
+	PriceStreamLength int64         // price ticks kept in the stream of every currency; zero means 10000
+	SessionTTL        time.Duration // sessions expire after this long without a refresh; zero means 24h
 }
 
 type RedisHandler interface {
@@ -23,34 +28,96 @@ type RedisHandler interface {
 	AddToList(key string, values ...string) error
 	GetList(key string) ([]string, error)
 
-	AddOperation(currency string, price float64) error
-	GetOrUpdateUserToken(userID uint64, expiresAt *time.Time) (time.Time, error)
+	AddOperation(currency string, price decimal.Decimal) error
+	GetOperationsCount(currency string) (int64, error)
+	GetPrices(currency string, from, to time.Time) ([]*PricePoint, error)
+	GetLastPrice(currency string) (*PricePoint, error)
+
+	PublishPrice(currency string, price decimal.Decimal) (string, error)
+	SubscribePrices(ctx context.Context, currency string, fromID string) (<-chan *PriceTick, error)
+
+	RecordTrade(currency string, price, amount decimal.Decimal, at time.Time) error
+	GetCandles(currency string, interval CandleInterval, from, to time.Time) ([]*Candle, error)
+
+	CreateSession(userID uint64, meta SessionMetadata) (*Session, error)
+	GetSession(id string) (*Session, error)
+	RefreshSession(id string) (*Session, error)
+	RevokeSession(id string) error
+	RevokeUserSessions(userID uint64) error
+	ListSessions(userID uint64) ([]*Session, error)
 }
 
 type redisClient struct {
-	client *redis.Client
+	client            *redis.Client
+	priceRetention    time.Duration
+	priceStreamLength int64
+	sessionTTL        time.Duration
 }
 
-func (rs *RedisSettings) Connect() RedisHandler {
+// MustConnect is Connect with the default retry options that panics when the server stays unreachable.
+func (rs *RedisSettings) MustConnect() RedisHandler {
+	rh, err := rs.Connect(context.Background(), retry.DefaultOptions())
+	if err != nil {
+		panic(err)
+	}
+
+	return rh
+}
+
+// Connect pings the server until it answers, retrying with backoff as opts tell.
+func (rs *RedisSettings) Connect(ctx context.Context, opts retry.Options) (RedisHandler, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", rs.Host, rs.Port),
 		Password: rs.Password,
 		DB:       0,
 	})
 
-	status := rdb.Ping(context.Background())
-	if status.Err() != nil {
-		panic(fmt.Sprintf("cannot connect to the redis server %v", status.Err()))
+	err := retry.Do(ctx, opts, "connecting to redis", func(ctx context.Context) error {
+		err := rdb.Ping(ctx).Err()
+		if err != nil {
+			if isAuthError(err) {
+				err = retry.Permanent(err)
+			}
+
+			return fmt.Errorf("cannot connect to the redis server; err: %w", classify(err))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		rdb.Close()
+		return nil, err
 	}
 
-	return &redisClient{client: rdb}
+	priceRetention := rs.PriceRetention
+	if priceRetention <= 0 {
+		priceRetention = defaultPriceRetention
+	}
+
+	priceStreamLength := rs.PriceStreamLength
+	if priceStreamLength <= 0 {
+		priceStreamLength = defaultPriceStreamLength
+	}
+
+	sessionTTL := rs.SessionTTL
+	if sessionTTL <= 0 {
+		sessionTTL = defaultSessionTTL
+	}
+
+	return &redisClient{
+		client:            rdb,
+		priceRetention:    priceRetention,
+		priceStreamLength: priceStreamLength,
+		sessionTTL:        sessionTTL,
+	}, nil
 }
 
 func (rc *redisClient) Set(key string, value string) error {
 	err := rc.client.Set(context.Background(), key, value, 0).Err()
 
 	if err != nil {
-		return fmt.Errorf("redis cannot set value(%v) with key (%v); err: %v", value, key, err)
+		return fmt.Errorf("redis cannot set value(%v) with key (%v); err: %w", value, key, classify(err))
 	}
 
 	return nil
@@ -60,7 +127,7 @@ func (rc *redisClient) AddToList(key string, values ...string) error {
 	err := rc.client.LPush(context.Background(), key, values).Err()
 
 	if err != nil {
-		return fmt.Errorf("redis cannot set value(%v) with key (%v); err: %v", values, key, err)
+		return fmt.Errorf("redis cannot set value(%v) with key (%v); err: %w", values, key, classify(err))
 	}
 
 	return nil
@@ -70,11 +137,7 @@ func (rc *redisClient) GetList(key string) ([]string, error) {
 	values, err := rc.client.LRange(context.Background(), key, 0, -1).Result()
 
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return nil, err
-		}
-
-		return nil, fmt.Errorf("redis cannot return value with key %v; err: %v", key, err)
+		return nil, fmt.Errorf("redis cannot return value with key %v; err: %w", key, classify(err))
 	}
 	return values, nil
 }
@@ -83,11 +146,7 @@ func (rc *redisClient) Get(key string) (string, error) {
 	val, err := rc.client.Get(context.Background(), key).Result()
 
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return "", err
-		}
-
-		return "", fmt.Errorf("redis cannot return value with key %v; err: %v", key, err)
+		return "", fmt.Errorf("redis cannot return value with key %v; err: %w", key, classify(err))
 	}
 
 	return val, nil
@@ -96,7 +155,7 @@ func (rc *redisClient) Get(key string) (string, error) {
 func (rc *redisClient) Remove(keys ...string) error {
 	err := rc.client.Del(context.Background(), keys...).Err()
 	if err != nil {
-		return fmt.Errorf("redis cannot delete keys %v; err: %v", keys, err)
+		return fmt.Errorf("redis cannot delete keys %v; err: %w", keys, classify(err))
 	}
 
 	return nil
@@ -108,56 +167,12 @@ func (rc *redisClient) Increment(keys ...string) error {
 		internalErr := rc.client.Incr(context.Background(), key).Err()
 		if internalErr != nil {
 			if err == nil {
-				err = fmt.Errorf("cannot increment value by the key %v; err: %v", key, internalErr)
+				err = fmt.Errorf("cannot increment value by the key %v; err: %w", key, classify(internalErr))
 			} else {
-				err = fmt.Errorf("%v; cannot increment value by the key %v", err, key)
+				err = fmt.Errorf("%w; cannot increment value by the key %v", err, key)
 			}
 		}
 	}
 
 	return err
 }
-
-func (rc *redisClient) AddOperation(currency string, price float64) error {
-	err := rc.client.LPush(
-		context.Background(),
-		currency+RedisCurrencyOperationsSuffix,
-		price,
-	)
-
-	if err != nil {
-		return fmt.Errorf("cannot insert price (%v) of the currency(%v); err: %v", price, currency, err)
-	}
-
-	return nil
-}
-
-func (rc *redisClient) GetOrUpdateUserToken(userID uint64, expiresAt *time.Time) (time.Time, error) {
-	curTime, err := rc.Get(fmt.Sprintf("%v%v", userID, UserTokenSuffix))
-	if err != nil {
-		errMsg := fmt.Sprintf("cannot get user's (id = %v) expiresAt time; err: %v", userID, err)
-
-		if expiresAt == nil {
-			return time.Now(), errors.New(errMsg)
-		}
-	}
-
-	if expiresAt != nil {
-		err = rc.Set(fmt.Sprintf("%v%v", userID, UserTokenSuffix), expiresAt.Format(time.RFC3339))
-		if err != nil {
-			return time.Now(), fmt.Errorf("cannot set user's (id = %v) expiresAt time; err: %v", userID, err)
-		}
-	}
-
-	if curTime != "" {
-
-		curTokenExpiresAt, err := time.Parse(time.RFC3339, curTime)
-		if err != nil {
-			return time.Now(), fmt.Errorf("cannot parse time %v as time.RFC3339; err: %v", curTime, err)
-		}
-		return curTokenExpiresAt, nil
-	}
-
-	return time.Now(), nil
-
-}
@@ -0,0 +1,326 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+)
+
+const defaultSessionTTL = 24 * time.Hour
+
+// Session is a signed-in device of the user. A session expires SessionTTL after it was last seen.
+type Session struct {
+	ID         string
+	UserID     uint64
+	Device     string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
+
+type SessionMetadata struct {
+	Device string
+	IP     string
+}
+
+// Session IDs are "<user id>.<random>", so the keys of a session can be found from its ID alone.
+// Keys of all sessions of a user share the user's hash tag, so scripts that touch them work on Redis Cluster.
+
+// refreshSession extends the session if it still exists and returns its fields.
+//
+// KEYS: session key, user sessions key. ARGV: TTL (ms), now (ns), expiry (ms), session ID.
+var refreshSession = redis.NewScript(`
+if redis.call('PEXPIRE', KEYS[1], ARGV[1]) == 0 then
+	return nil
+end
+
+redis.call('HSET', KEYS[1], 'last_seen_at', ARGV[2])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[4])
+redis.call('PEXPIRE', KEYS[2], ARGV[1])
+
+return redis.call('HGETALL', KEYS[1])
+`)
+
+// revokeUserSessions deletes every session of the user along with the index, so a session created
+// meanwhile is either revoked as well or created after the revocation.
+//
+// KEYS: user sessions key. ARGV: prefix of the session keys of the user.
+var revokeUserSessions = redis.NewScript(`
+local ids = redis.call('ZRANGE', KEYS[1], 0, -1)
+
+for _, id in ipairs(ids) do
+	redis.call('DEL', ARGV[1] .. id)
+end
+
+redis.call('DEL', KEYS[1])
+
+return #ids
+`)
+
+// CreateSession starts a session of the user with a random ID.
+func (rc *redisClient) CreateSession(userID uint64, meta SessionMetadata) (*Session, error) {
+	id, err := newSessionID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:         id,
+		UserID:     userID,
+		Device:     meta.Device,
+		IP:         meta.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(rc.sessionTTL),
+	}
+
+	_, err = rc.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.HSet(
+			context.Background(),
+			sessionKey(id),
+			"user_id", userID,
+			"device", meta.Device,
+			"ip", meta.IP,
+			"created_at", now.UnixNano(),
+			"last_seen_at", now.UnixNano(),
+		)
+		pipe.PExpire(context.Background(), sessionKey(id), rc.sessionTTL)
+		pipe.ZAdd(context.Background(), userSessionsKey(userID), redis.Z{Score: float64(session.ExpiresAt.UnixMilli()), Member: id})
+		pipe.PExpire(context.Background(), userSessionsKey(userID), rc.sessionTTL)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot create a session of the user (id = %v); err: %w", userID, classify(err))
+	}
+
+	return session, nil
+}
+
+// GetSession returns the session or ErrNotFound if it expired or was revoked.
+func (rc *redisClient) GetSession(id string) (*Session, error) {
+	if _, ok := sessionUserID(id); !ok {
+		return nil, fmt.Errorf("%w; invalid session id", ErrNotFound)
+	}
+
+	pipe := rc.client.Pipeline()
+	fieldsCmd := pipe.HGetAll(context.Background(), sessionKey(id))
+	ttlCmd := pipe.PTTL(context.Background(), sessionKey(id))
+
+	_, err := pipe.Exec(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the session; err: %w", classify(err))
+	}
+
+	if len(fieldsCmd.Val()) == 0 {
+		return nil, fmt.Errorf("%w; session does not exist or expired", ErrNotFound)
+	}
+
+	return decodeSession(id, fieldsCmd.Val(), time.Now().Add(ttlCmd.Val()))
+}
+
+// RefreshSession marks the session as seen now and extends it by SessionTTL.
+// It returns ErrNotFound if the session expired or was revoked.
+func (rc *redisClient) RefreshSession(id string) (*Session, error) {
+	userID, ok := sessionUserID(id)
+	if !ok {
+		return nil, fmt.Errorf("%w; invalid session id", ErrNotFound)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(rc.sessionTTL)
+
+	res, err := refreshSession.Run(
+		context.Background(),
+		rc.client,
+		[]string{sessionKey(id), userSessionsKey(userID)},
+		rc.sessionTTL.Milliseconds(),
+		now.UnixNano(),
+		expiresAt.UnixMilli(),
+		id,
+	).StringSlice()
+
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("%w; session does not exist or expired", ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("cannot refresh the session; err: %w", classify(err))
+	}
+
+	fields := make(map[string]string, len(res)/2)
+	for i := 0; i+1 < len(res); i += 2 {
+		fields[res[i]] = res[i+1]
+	}
+
+	return decodeSession(id, fields, expiresAt)
+}
+
+// RevokeSession ends the session; revoking a session that does not exist is not an error.
+func (rc *redisClient) RevokeSession(id string) error {
+	userID, ok := sessionUserID(id)
+	if !ok {
+		return nil
+	}
+
+	_, err := rc.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Del(context.Background(), sessionKey(id))
+		pipe.ZRem(context.Background(), userSessionsKey(userID), id)
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("cannot revoke the session; err: %w", classify(err))
+	}
+
+	return nil
+}
+
+// RevokeUserSessions ends every session of the user, e.g. after a password change.
+func (rc *redisClient) RevokeUserSessions(userID uint64) error {
+	err := revokeUserSessions.Run(context.Background(), rc.client, []string{userSessionsKey(userID)}, userSessionKeyPrefix(userID)).Err()
+	if err != nil {
+		return fmt.Errorf("cannot revoke sessions of the user (id = %v); err: %w", userID, classify(err))
+	}
+
+	return nil
+}
+
+// ListSessions returns active sessions of the user, the most recently created first.
+func (rc *redisClient) ListSessions(userID uint64) ([]*Session, error) {
+	key := userSessionsKey(userID)
+
+	err := rc.client.ZRemRangeByScore(context.Background(), key, "-inf", "("+strconv.FormatInt(time.Now().UnixMilli(), 10)).Err()
+	if err != nil {
+		return nil, fmt.Errorf("cannot remove expired sessions of the user (id = %v); err: %w", userID, classify(err))
+	}
+
+	ids, err := rc.client.ZRange(context.Background(), key, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get sessions of the user (id = %v); err: %w", userID, classify(err))
+	}
+
+	fieldsCmds := make([]*redis.MapStringStringCmd, 0, len(ids))
+	ttlCmds := make([]*redis.DurationCmd, 0, len(ids))
+
+	_, err = rc.client.Pipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		for _, id := range ids {
+			fieldsCmds = append(fieldsCmds, pipe.HGetAll(context.Background(), sessionKey(id)))
+			ttlCmds = append(ttlCmds, pipe.PTTL(context.Background(), sessionKey(id)))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot get sessions of the user (id = %v); err: %w", userID, classify(err))
+	}
+
+	now := time.Now()
+	sessions := make([]*Session, 0, len(ids))
+
+	for i := range ids {
+		if len(fieldsCmds[i].Val()) == 0 {
+			continue // revoked or expired after the listing
+		}
+
+		session, err := decodeSession(ids[i], fieldsCmds[i].Val(), now.Add(ttlCmds[i].Val()))
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.After(sessions[j].CreatedAt) })
+
+	return sessions, nil
+}
+
+const (
+	sessionKeyPrefix      = "session:"
+	userSessionsKeyPrefix = "user_sessions:"
+)
+
+// sessionKey expects an ID sessionUserID accepts.
+func sessionKey(id string) string {
+	userID, _ := sessionUserID(id)
+	return userSessionKeyPrefix(userID) + id
+}
+
+func userSessionKeyPrefix(userID uint64) string {
+	return sessionKeyPrefix + hashTag(userID) + ":"
+}
+
+func userSessionsKey(userID uint64) string {
+	return userSessionsKeyPrefix + hashTag(userID)
+}
+
+func hashTag(userID uint64) string {
+	return "{" + strconv.FormatUint(userID, 10) + "}"
+}
+
+func newSessionID(userID uint64) (string, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate a session id; err: %w", err)
+	}
+
+	return strconv.FormatUint(userID, 10) + "." + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sessionUserID returns the ID of the user the session ID was made for.
+func sessionUserID(id string) (uint64, bool) {
+	userID, random, ok := strings.Cut(id, ".")
+	if !ok || random == "" {
+		return 0, false
+	}
+
+	parsed, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return parsed, true
+}
+
+func decodeSession(id string, fields map[string]string, expiresAt time.Time) (*Session, error) {
+	userID, err := strconv.ParseUint(fields["user_id"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id of the session; err: %w", err)
+	}
+
+	createdAt, err := strconv.ParseInt(fields["created_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid creation time of the session; err: %w", err)
+	}
+
+	lastSeenAt, err := strconv.ParseInt(fields["last_seen_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid last seen time of the session; err: %w", err)
+	}
+
+	return &Session{
+		ID:         id,
+		UserID:     userID,
+		Device:     fields["device"],
+		IP:         fields["ip"],
+		CreatedAt:  time.Unix(0, createdAt),
+		LastSeenAt: time.Unix(0, lastSeenAt),
+		ExpiresAt:  expiresAt,
+	}, nil
+}
@@ -0,0 +1,150 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Logger receives a line for every failed attempt. *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Options configure exponential backoff: the n-th wait is InitialInterval * Multiplier^(n-1),
+// capped by MaxInterval and randomized by ±Jitter of its value.
+type Options struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	Jitter          float64       // from 0 to 1
+	MaxElapsedTime  time.Duration // retrying stops after that long; zero means until ctx is done
+	Logger          Logger        // nil disables logging
+}
+
+// DefaultOptions suit waiting for a dependency that is still starting, e.g. in docker-compose or k8s.
+func DefaultOptions() Options {
+	return Options{
+		InitialInterval: 500 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		MaxElapsedTime:  2 * time.Minute,
+	}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks an error that retrying cannot fix, e.g. failed authentication, so Do returns it
+// without retrying. The error stays in the chain for errors.Is and errors.As. Permanent(nil) is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Do calls fn until it succeeds, returns a Permanent error, ctx is done or MaxElapsedTime passes,
+// and returns the last error. name is only used in log lines and errors.
+func Do(ctx context.Context, opts Options, name string, fn func(ctx context.Context) error) error {
+	opts = opts.withDefaults()
+
+	if opts.MaxElapsedTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxElapsedTime)
+		defer cancel()
+	}
+
+	interval := opts.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			if attempt > 1 {
+				opts.logf("%v succeeded after %v attempts", name, attempt)
+			}
+
+			return nil
+		}
+
+		if IsPermanent(err) {
+			return fmt.Errorf("%v failed after %v attempts; err: %w", name, attempt, err)
+		}
+
+		wait := opts.jitter(interval)
+		opts.logf("%v failed (attempt %v), retrying in %v; err: %v", name, attempt, wait, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%v failed after %v attempts; err: %w", name, attempt, err)
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * opts.Multiplier)
+		if interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
+
+func (o Options) withDefaults() Options {
+	defaults := DefaultOptions()
+
+	if o.InitialInterval <= 0 {
+		o.InitialInterval = defaults.InitialInterval
+	}
+
+	if o.MaxInterval < o.InitialInterval {
+		o.MaxInterval = o.InitialInterval
+	}
+
+	if o.Multiplier < 1 {
+		o.Multiplier = 1
+	}
+
+	if o.Jitter < 0 {
+		o.Jitter = 0
+	}
+
+	if o.Jitter > 1 {
+		o.Jitter = 1
+	}
+
+	return o
+}
+
+func (o Options) jitter(interval time.Duration) time.Duration {
+	if o.Jitter == 0 {
+		return interval
+	}
+
+	delta := o.Jitter * float64(interval)
+	return time.Duration(float64(interval) - delta + rand.Float64()*2*delta)
+}
+
+func (o Options) logf(format string, v ...interface{}) {
+	if o.Logger != nil {
+		o.Logger.Printf(format, v...)
+	}
+}
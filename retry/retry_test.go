@@ -0,0 +1,170 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithDefaults(t *testing.T) {
+	defaults := DefaultOptions()
+
+	tests := []struct {
+		name string
+		opts Options
+		want Options
+	}{
+		{
+			name: "zero options",
+			opts: Options{},
+			want: Options{InitialInterval: defaults.InitialInterval, MaxInterval: defaults.InitialInterval, Multiplier: 1},
+		},
+		{
+			name: "max interval below the initial one",
+			opts: Options{InitialInterval: time.Second, MaxInterval: time.Millisecond, Multiplier: 2},
+			want: Options{InitialInterval: time.Second, MaxInterval: time.Second, Multiplier: 2},
+		},
+		{
+			name: "jitter below zero",
+			opts: Options{InitialInterval: time.Second, MaxInterval: time.Minute, Multiplier: 2, Jitter: -1},
+			want: Options{InitialInterval: time.Second, MaxInterval: time.Minute, Multiplier: 2, Jitter: 0},
+		},
+		{
+			name: "jitter above one",
+			opts: Options{InitialInterval: time.Second, MaxInterval: time.Minute, Multiplier: 2, Jitter: 3},
+			want: Options{InitialInterval: time.Second, MaxInterval: time.Minute, Multiplier: 2, Jitter: 1},
+		},
+		{
+			name: "valid options are kept",
+			opts: defaults,
+			want: defaults,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.withDefaults(); got != tt.want {
+				t.Errorf("withDefaults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	const interval = time.Second
+
+	for _, jitter := range []float64{0, 0.2, 1} {
+		opts := Options{Jitter: jitter}
+		low := time.Duration(float64(interval) * (1 - jitter))
+		high := time.Duration(float64(interval) * (1 + jitter))
+
+		for i := 0; i < 1000; i++ {
+			if got := opts.jitter(interval); got < low || got > high {
+				t.Fatalf("jitter(%v) with Jitter = %v is %v, want it within [%v, %v]", interval, jitter, got, low, high)
+			}
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errFatal := errors.New("fatal")
+
+	fast := Options{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1}
+
+	tests := []struct {
+		name         string
+		opts         Options
+		failures     int   // calls that fail before fn succeeds; -1 fails forever
+		fail         error // error of the failing calls
+		cancelAfter  int   // ctx is cancelled after that many calls; zero never
+		wantErr      error
+		wantCalls    int // zero means at least two
+		wantMaxSpent time.Duration
+	}{
+		{
+			name:      "first call succeeds",
+			opts:      fast,
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeds after failures",
+			opts:      fast,
+			failures:  3,
+			fail:      errTemporary,
+			wantCalls: 4,
+		},
+		{
+			name:      "permanent error is not retried",
+			opts:      fast,
+			failures:  -1,
+			fail:      Permanent(errFatal),
+			wantErr:   errFatal,
+			wantCalls: 1,
+		},
+		{
+			name:        "cancelled ctx stops retrying",
+			opts:        Options{InitialInterval: time.Hour, MaxInterval: time.Hour, Multiplier: 1},
+			failures:    -1,
+			fail:        errTemporary,
+			cancelAfter: 1,
+			wantErr:     errTemporary,
+			wantCalls:   1,
+		},
+		{
+			name:         "retrying stops after MaxElapsedTime",
+			opts:         Options{InitialInterval: 5 * time.Millisecond, MaxInterval: 5 * time.Millisecond, Multiplier: 1, MaxElapsedTime: 50 * time.Millisecond},
+			failures:     -1,
+			fail:         errTemporary,
+			wantErr:      errTemporary,
+			wantMaxSpent: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			calls := 0
+			started := time.Now()
+
+			err := Do(ctx, tt.opts, "test", func(ctx context.Context) error {
+				calls++
+
+				if calls == tt.cancelAfter {
+					cancel()
+				}
+
+				if tt.failures < 0 || calls <= tt.failures {
+					return tt.fail
+				}
+
+				return nil
+			})
+
+			spent := time.Since(started)
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Do() err = %v", err)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Do() err = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantCalls > 0 && calls != tt.wantCalls {
+				t.Errorf("fn was called %v times, want %v", calls, tt.wantCalls)
+			}
+
+			if tt.wantCalls == 0 && calls < 2 {
+				t.Errorf("fn was called %v times, want it retried", calls)
+			}
+
+			if tt.wantMaxSpent > 0 && spent > tt.wantMaxSpent {
+				t.Errorf("Do() returned after %v, want at most %v", spent, tt.wantMaxSpent)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package rmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Kana-v1-exchange/enviroment/protos/events"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// eventsExchange is the topic exchange events are published to; only the queues of subscribers are bound to it.
+const eventsExchange = "exchanges.events"
+
+type correlationIDKey struct{}
+
+// EventHandler handles the payload of an envelope; event has the type the handler was registered for.
+type EventHandler func(ctx context.Context, envelope *events.Envelope, event proto.Message) error
+
+// EventHandlers maps envelope types (see events.TypeOf) to their handlers.
+type EventHandlers map[string]EventHandler
+
+// WithCorrelationID returns a context whose published events carry the correlation ID.
+// Subscribe passes handlers a context with the correlation ID of the envelope they handle.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// EncodedEvent is an event envelope encoded ahead of publishing, e.g. to be stored in an outbox.
+type EncodedEvent struct {
+	ID            string
+	Type          string
+	RoutingKey    string
+	CorrelationID string
+	ContentType   string
+	Timestamp     time.Time
+	Body          []byte
+}
+
+// EncodeEvent wraps event in an envelope with the correlation ID of ctx and encodes it with contentType.
+func EncodeEvent(ctx context.Context, event proto.Message, contentType string) (*EncodedEvent, error) {
+	envelope, err := events.Wrap(event, CorrelationID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeEnvelope(envelope, event, contentType)
+}
+
+// EncodeEventWithID is EncodeEvent with the envelope and message ID chosen by the caller.
+func EncodeEventWithID(ctx context.Context, id string, event proto.Message, contentType string) (*EncodedEvent, error) {
+	envelope, err := events.WrapWithID(event, id, CorrelationID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeEnvelope(envelope, event, contentType)
+}
+
+func encodeEnvelope(envelope *events.Envelope, event proto.Message, contentType string) (*EncodedEvent, error) {
+	body, err := events.Encode(envelope, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode %v %v; err: %w", envelope.Type, envelope.Id, err)
+	}
+
+	return &EncodedEvent{
+		ID:            envelope.Id,
+		Type:          envelope.Type,
+		RoutingKey:    events.RoutingKey(event),
+		CorrelationID: envelope.CorrelationID,
+		ContentType:   contentType,
+		Timestamp:     envelope.Timestamp.AsTime(),
+		Body:          body,
+	}, nil
+}
+
+// Publish wraps event in an envelope and publishes it with RMQSettings.ContentType to the events exchange
+// under events.RoutingKey of the event. With publisher confirms enabled it waits for the confirm
+// like WriteConfirmed, otherwise it behaves like Write.
+func (rc *rmqClient) Publish(ctx context.Context, event proto.Message) error {
+	encoded, err := EncodeEvent(ctx, event, rc.settings.contentType())
+	if err != nil {
+		return err
+	}
+
+	if rc.settings.PublisherConfirms {
+		err = rc.writeConfirmed(ctx, encoded.outgoing())
+	} else {
+		err = rc.write(encoded.outgoing())
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot publish %v %v; err: %w", encoded.Type, encoded.ID, err)
+	}
+
+	return nil
+}
+
+// PublishConfirmed publishes an encoded event and waits for the broker to confirm it.
+// It requires publisher confirms to be enabled.
+func (rc *rmqClient) PublishConfirmed(ctx context.Context, event *EncodedEvent) error {
+	err := rc.writeConfirmed(ctx, event.outgoing())
+	if err != nil {
+		return fmt.Errorf("cannot publish %v %v; err: %w", event.Type, event.ID, err)
+	}
+
+	return nil
+}
+
+// Subscribe consumes envelopes of every event from the durable queue named queue and dispatches them by type.
+// The queue is the subscriber's own: it is bound to all events and outlives restarts, and subscribers
+// that share its name share the events. Envelopes without a handler are acked and skipped, ones that
+// cannot be decoded are rejected. A handler error retries the message like Consume does.
+func (rc *rmqClient) Subscribe(ctx context.Context, queue string, opts ConsumeOptions, handlers EventHandlers) error {
+	if queue == "" || queue == queueName {
+		return fmt.Errorf("cannot subscribe to events: invalid queue '%v'", queue)
+	}
+
+	return rc.subscribe(ctx, opts, rc.dispatchEvents(handlers), &subscription{
+		name: queue,
+		declare: func(ch *amqp.Channel) (string, error) {
+			_, err := ch.QueueDeclare(queue, true, false, false, false, nil)
+			if err != nil {
+				return "", fmt.Errorf("cannot create the '%v' queue; err: %w", queue, classify(err))
+			}
+
+			err = ch.QueueBind(queue, "#", eventsExchange, false, nil)
+			if err != nil {
+				return "", fmt.Errorf("cannot bind the '%v' queue to the '%v' exchange; err: %w", queue, eventsExchange, classify(err))
+			}
+
+			err = bindDeadLetters(ch, queue)
+			if err != nil {
+				return "", err
+			}
+
+			return queue, nil
+		},
+		failed: func(ctx context.Context, msg *Message, cause error) error {
+			return rc.retryLater(ctx, queue, msg, cause)
+		},
+	})
+}
+
+// SubscribeTopics is Subscribe for events whose routing keys match one of the patterns, e.g. "price.EUR"
+// or "trade.*.USD". The events come to a queue of the subscriber's own that is deleted when it stops,
+// so every subscriber gets all matching events. A message the handler fails is redelivered once and then dropped.
+func (rc *rmqClient) SubscribeTopics(ctx context.Context, opts ConsumeOptions, patterns []string, handlers EventHandlers) error {
+	if len(patterns) == 0 {
+		return errors.New("cannot subscribe to events: no routing key patterns")
+	}
+
+	return rc.subscribe(ctx, opts, rc.dispatchEvents(handlers), &subscription{
+		name: eventsExchange,
+		declare: func(ch *amqp.Channel) (string, error) {
+			queue, err := ch.QueueDeclare("", false, true, true, false, nil)
+			if err != nil {
+				return "", fmt.Errorf("cannot create a queue for the events %v; err: %w", patterns, classify(err))
+			}
+
+			for _, pattern := range patterns {
+				err = ch.QueueBind(queue.Name, pattern, eventsExchange, false, nil)
+				if err != nil {
+					return "", fmt.Errorf("cannot bind the queue '%v' to the events %v; err: %w", queue.Name, pattern, classify(err))
+				}
+			}
+
+			return queue.Name, nil
+		},
+		failed: func(ctx context.Context, msg *Message, cause error) error {
+			rc.logf("cannot handle event %v; err: %v", msg.delivery.MessageId, cause)
+			return msg.Nack(!msg.Redelivered)
+		},
+	})
+}
+
+func (rc *rmqClient) dispatchEvents(handlers EventHandlers) Handler {
+	return func(ctx context.Context, msg *Message) error {
+		envelope, err := events.Decode(msg.Body, msg.delivery.ContentType)
+		if err != nil {
+			rc.logf("rejecting message %v; err: %v", msg.delivery.MessageId, err)
+			return msg.Reject()
+		}
+
+		handler, ok := handlers[envelope.Type]
+		if !ok {
+			return msg.Ack()
+		}
+
+		event, err := envelope.Unwrap()
+		if err != nil {
+			rc.logf("rejecting message %v; err: %v", envelope.Id, err)
+			return msg.Reject()
+		}
+
+		return handler(WithCorrelationID(ctx, envelope.CorrelationID), envelope, event)
+	}
+}
+
+func (e *EncodedEvent) outgoing() *outgoing {
+	return &outgoing{
+		exchange: eventsExchange,
+		key:      e.RoutingKey,
+		publishing: amqp.Publishing{
+			ContentType:   e.ContentType,
+			MessageId:     e.ID,
+			CorrelationId: e.CorrelationID,
+			Type:          e.Type,
+			Timestamp:     e.Timestamp,
+			DeliveryMode:  amqp.Persistent,
+			Body:          e.Body,
+		},
+		// an event nobody subscribed to is not an error
+		optional: true,
+	}
+}
+
+func (rmqS *RMQSettings) contentType() string {
+	if rmqS.ContentType != "" {
+		return rmqS.ContentType
+	}
+
+	return events.ContentTypeProtobuf
+}
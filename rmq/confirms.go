@@ -0,0 +1,248 @@
+package rmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/Kana-v1-exchange/enviroment/retry"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const notifyBufferSize = 64
+
+// confirmTracker matches broker confirms and returns of one channel with the publishings
+// waiting for them. Publishings nobody waits for are only logged when they fail.
+type confirmTracker struct {
+	logger retry.Logger
+
+	mu       sync.Mutex
+	waiting  map[uint64]*waiter // delivery tag -> publishing waiting for its confirm
+	tags     map[string]uint64  // message id -> delivery tag of waiting publishings
+	returned map[uint64]*amqp.Return
+}
+
+type waiter struct {
+	messageID string
+	outcome   chan error
+}
+
+// newConfirmTracker starts tracking ch; it stops when ch is closed and fails every publishing
+// still waiting with ErrConnectionLost. The channel must already be in confirm mode if confirms is set.
+func newConfirmTracker(ch *amqp.Channel, confirms bool, logger retry.Logger) *confirmTracker {
+	t := &confirmTracker{
+		logger:   logger,
+		waiting:  make(map[uint64]*waiter),
+		tags:     make(map[string]uint64),
+		returned: make(map[uint64]*amqp.Return),
+	}
+
+	returns := ch.NotifyReturn(make(chan amqp.Return, notifyBufferSize))
+
+	var acks chan amqp.Confirmation
+	if confirms {
+		acks = ch.NotifyPublish(make(chan amqp.Confirmation, notifyBufferSize))
+	}
+
+	go t.run(returns, acks)
+
+	return t
+}
+
+// WriteConfirmed publishes msg like Write and waits until the broker confirms it.
+// It fails with ErrNacked when the broker rejects the message and with ErrUnroutable
+// when no queue takes it. Messages are not buffered while the connection is being restored.
+func (rc *rmqClient) WriteConfirmed(ctx context.Context, msg string) error {
+	err := rc.writeConfirmed(ctx, &outgoing{
+		key: queueName,
+		publishing: amqp.Publishing{
+			ContentType: "text/plain",
+			Body:        []byte(msg),
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("cannot publish message '%s'; err: %w", msg, err)
+	}
+
+	return nil
+}
+
+// writeConfirmed publishes the message and waits for its confirm. The message ID identifies
+// the message if the broker returns it; the delivery tag is used when it is empty.
+func (rc *rmqClient) writeConfirmed(ctx context.Context, msg *outgoing) error {
+	if !rc.settings.PublisherConfirms {
+		return errors.New("publisher confirms are disabled")
+	}
+
+	rc.mu.Lock()
+
+	switch rc.state {
+	case StateClosed:
+		rc.mu.Unlock()
+		return ErrClosed
+	case StateConnecting:
+		rc.mu.Unlock()
+		return ErrConnectionLost
+	}
+
+	tag := rc.ch.GetNextPublishSeqNo()
+	if msg.publishing.MessageId == "" {
+		msg.publishing.MessageId = strconv.FormatUint(tag, 10)
+	}
+
+	tracker := rc.confirms
+	outcome := tracker.track(tag, msg.publishing.MessageId)
+
+	err := rc.publish(msg)
+	rc.mu.Unlock()
+
+	if err != nil {
+		tracker.forget(tag)
+		return classify(err)
+	}
+
+	select {
+	case err = <-outcome:
+		if err != nil {
+			return fmt.Errorf("message was not delivered; err: %w", err)
+		}
+
+		return nil
+	case <-ctx.Done():
+		tracker.forget(tag)
+		return fmt.Errorf("message was not confirmed; err: %w", ctx.Err())
+	}
+}
+
+// track must be called before the publishing with the tag is sent.
+func (t *confirmTracker) track(tag uint64, messageID string) <-chan error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w := &waiter{messageID: messageID, outcome: make(chan error, 1)}
+	t.waiting[tag] = w
+	t.tags[messageID] = tag
+
+	return w.outcome
+}
+
+func (t *confirmTracker) forget(tag uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.remove(tag)
+}
+
+func (t *confirmTracker) run(returns chan amqp.Return, acks chan amqp.Confirmation) {
+	defer t.failAll()
+
+	for {
+		select {
+		case ret, ok := <-returns:
+			if !ok {
+				if acks == nil {
+					return
+				}
+
+				returns = nil
+				continue
+			}
+
+			t.handleReturn(ret)
+		case confirm, ok := <-acks:
+			if !ok {
+				return
+			}
+
+			// the broker sends basic.return before the confirm of the same message,
+			// so it is already in returns if the message was unroutable
+			t.drainReturns(returns)
+			t.handleConfirm(confirm)
+		}
+	}
+}
+
+func (t *confirmTracker) drainReturns(returns chan amqp.Return) {
+	for {
+		select {
+		case ret, ok := <-returns:
+			if !ok {
+				return
+			}
+
+			t.handleReturn(ret)
+		default:
+			return
+		}
+	}
+}
+
+func (t *confirmTracker) handleReturn(ret amqp.Return) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tag, ok := t.tags[ret.MessageId]
+	if !ok {
+		t.logf("message '%s' was returned by the broker: %v %v", ret.Body, ret.ReplyCode, ret.ReplyText)
+		return
+	}
+
+	t.returned[tag] = &ret
+}
+
+func (t *confirmTracker) handleConfirm(confirm amqp.Confirmation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.waiting[confirm.DeliveryTag]
+	ret := t.returned[confirm.DeliveryTag]
+	t.remove(confirm.DeliveryTag)
+
+	var err error
+	switch {
+	case ret != nil:
+		err = fmt.Errorf("%w: %v %v", ErrUnroutable, ret.ReplyCode, ret.ReplyText)
+	case !confirm.Ack:
+		err = ErrNacked
+	}
+
+	if !ok {
+		if err != nil {
+			t.logf("publishing %v was not delivered; err: %v", confirm.DeliveryTag, err)
+		}
+
+		return
+	}
+
+	w.outcome <- err
+}
+
+func (t *confirmTracker) failAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for tag, w := range t.waiting {
+		w.outcome <- fmt.Errorf("channel was closed before the broker confirmed the message; err: %w", ErrConnectionLost)
+		t.remove(tag)
+	}
+}
+
+// remove must be called with mu held.
+func (t *confirmTracker) remove(tag uint64) {
+	w, ok := t.waiting[tag]
+	if ok && t.tags[w.messageID] == tag {
+		delete(t.tags, w.messageID)
+	}
+
+	delete(t.waiting, tag)
+	delete(t.returned, tag)
+}
+
+func (t *confirmTracker) logf(format string, v ...interface{}) {
+	if t.logger != nil {
+		t.logger.Printf(format, v...)
+	}
+}
@@ -0,0 +1,68 @@
+package rmq
+
+import (
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func newTestTracker() *confirmTracker {
+	return &confirmTracker{
+		waiting:  make(map[uint64]*waiter),
+		tags:     make(map[string]uint64),
+		returned: make(map[uint64]*amqp.Return),
+	}
+}
+
+func TestConfirmTracker(t *testing.T) {
+	tracker := newTestTracker()
+
+	acked := tracker.track(1, "acked")
+	returned := tracker.track(2, "returned")
+	nacked := tracker.track(3, "nacked")
+	forgotten := tracker.track(4, "forgotten")
+
+	tracker.handleReturn(amqp.Return{MessageId: "returned", ReplyCode: 312, ReplyText: "NO_ROUTE"})
+	tracker.handleConfirm(amqp.Confirmation{DeliveryTag: 1, Ack: true})
+	tracker.handleConfirm(amqp.Confirmation{DeliveryTag: 2, Ack: true})
+	tracker.handleConfirm(amqp.Confirmation{DeliveryTag: 3, Ack: false})
+	tracker.forget(4)
+
+	if err := <-acked; err != nil {
+		t.Errorf("acked publishing err = %v", err)
+	}
+
+	if err := <-returned; !errors.Is(err, ErrUnroutable) {
+		t.Errorf("returned publishing err = %v, want %v", err, ErrUnroutable)
+	}
+
+	if err := <-nacked; !errors.Is(err, ErrNacked) {
+		t.Errorf("nacked publishing err = %v, want %v", err, ErrNacked)
+	}
+
+	select {
+	case err := <-forgotten:
+		t.Errorf("forgotten publishing got an outcome: %v", err)
+	default:
+	}
+
+	if len(tracker.waiting)+len(tracker.tags)+len(tracker.returned) != 0 {
+		t.Errorf("tracker keeps %v waiting, %v tags and %v returns, want none", len(tracker.waiting), len(tracker.tags), len(tracker.returned))
+	}
+}
+
+func TestConfirmTrackerKeepsReusedMessageID(t *testing.T) {
+	tracker := newTestTracker()
+
+	tracker.track(1, "id")
+	second := tracker.track(2, "id")
+
+	tracker.handleConfirm(amqp.Confirmation{DeliveryTag: 1, Ack: true})
+	tracker.handleReturn(amqp.Return{MessageId: "id", ReplyCode: 312, ReplyText: "NO_ROUTE"})
+	tracker.handleConfirm(amqp.Confirmation{DeliveryTag: 2, Ack: true})
+
+	if err := <-second; !errors.Is(err, ErrUnroutable) {
+		t.Errorf("second publishing err = %v, want %v", err, ErrUnroutable)
+	}
+}
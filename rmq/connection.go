@@ -0,0 +1,238 @@
+package rmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Kana-v1-exchange/enviroment/retry"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type ConnectionState int
+
+const (
+	StateConnected  ConnectionState = iota
+	StateConnecting                 // the connection was lost and is being restored
+	StateClosed                     // Close was called
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateConnecting:
+		return "connecting"
+	case StateClosed:
+		return "closed"
+	}
+
+	return fmt.Sprintf("ConnectionState(%d)", int(s))
+}
+
+func (rc *rmqClient) State() ConnectionState {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.state
+}
+
+// NotifyState registers a listener for state changes and returns it. Changes are sent
+// without blocking, so a listener that is not drained misses them; use a buffered channel.
+// The channel is closed by Close.
+func (rc *rmqClient) NotifyState(receiver chan ConnectionState) chan ConnectionState {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.state == StateClosed {
+		close(receiver)
+		return receiver
+	}
+
+	rc.listeners = append(rc.listeners, receiver)
+	return receiver
+}
+
+// Close stops reconnecting, closes the connection and every channel returned by Read.
+// Messages that are still buffered are dropped.
+func (rc *rmqClient) Close() error {
+	rc.mu.Lock()
+	if rc.state == StateClosed {
+		rc.mu.Unlock()
+		return nil
+	}
+
+	rc.setState(StateClosed)
+	rc.cancel()
+	conn := rc.conn
+	rc.mu.Unlock()
+
+	var err error
+	if conn != nil && !conn.IsClosed() {
+		err = conn.Close()
+	}
+
+	rc.supervisor.Wait()
+	rc.forwarders.Wait()
+
+	rc.mu.Lock()
+	for _, out := range rc.consumers {
+		close(out)
+	}
+
+	for _, listener := range rc.listeners {
+		close(listener)
+	}
+
+	rc.consumers, rc.listeners, rc.pending = nil, nil, nil
+	rc.mu.Unlock()
+
+	if err != nil && !errors.Is(err, amqp.ErrClosed) {
+		return fmt.Errorf("cannot close the rmq connection; err: %w", classify(err))
+	}
+
+	return nil
+}
+
+// supervise waits until the connection or the channel is closed by the broker or the network
+// and restores both. It returns when Close is called.
+func (rc *rmqClient) supervise() {
+	defer rc.supervisor.Done()
+
+	for {
+		rc.mu.Lock()
+		connClosed := rc.conn.NotifyClose(make(chan *amqp.Error, 1))
+		chClosed := rc.ch.NotifyClose(make(chan *amqp.Error, 1))
+		rc.mu.Unlock()
+
+		select {
+		case <-rc.ctx.Done():
+			return
+		case <-connClosed:
+		case <-chClosed:
+		}
+
+		rc.mu.Lock()
+		if rc.state == StateClosed {
+			rc.mu.Unlock()
+			return
+		}
+
+		rc.setState(StateConnecting)
+		rc.conn.Close() // the channel may be closed alone; start over with a new connection
+		rc.mu.Unlock()
+
+		if !rc.reconnect() {
+			return
+		}
+	}
+}
+
+// reconnect dials the broker until it succeeds and restores consumers and buffered messages.
+// It reports false when the client was closed meanwhile.
+func (rc *rmqClient) reconnect() bool {
+	opts := rc.opts
+	opts.MaxElapsedTime = 0
+
+	for {
+		var conn *amqp.Connection
+		var ch *amqp.Channel
+
+		err := retry.Do(rc.ctx, opts, "reconnecting to rmq", func(ctx context.Context) error {
+			var err error
+			conn, ch, err = rc.settings.open()
+			return err
+		})
+
+		if err != nil {
+			return false
+		}
+
+		rc.mu.Lock()
+		if rc.state == StateClosed {
+			rc.mu.Unlock()
+			conn.Close()
+			return false
+		}
+
+		rc.useChannel(conn, ch)
+
+		err = rc.restore()
+		if err != nil {
+			rc.logf("cannot restore the rmq client after reconnecting, reconnecting again; err: %v", err)
+
+			conn.Close()
+			rc.mu.Unlock()
+			continue
+		}
+
+		rc.setState(StateConnected)
+		rc.mu.Unlock()
+
+		return true
+	}
+}
+
+// restore must be called with mu held. It restarts consumers and publishes buffered messages in order.
+func (rc *rmqClient) restore() error {
+	for _, out := range rc.consumers {
+		err := rc.consume(out)
+		if err != nil {
+			return err
+		}
+	}
+
+	for len(rc.pending) > 0 {
+		err := rc.publish(rc.pending[0])
+		if err != nil {
+			return fmt.Errorf("cannot publish a buffered message; err: %w", classify(err))
+		}
+
+		rc.pending = rc.pending[1:]
+	}
+
+	return nil
+}
+
+// setState must be called with mu held.
+func (rc *rmqClient) setState(state ConnectionState) {
+	switch {
+	case state == StateConnecting && rc.state == StateConnected:
+		rc.connected = make(chan struct{})
+	case state == StateConnected && rc.state == StateConnecting:
+		close(rc.connected)
+	}
+
+	rc.state = state
+
+	for _, listener := range rc.listeners {
+		select {
+		case listener <- state:
+		default:
+		}
+	}
+}
+
+// waitConnected returns the current connection once the client is connected.
+func (rc *rmqClient) waitConnected(ctx context.Context) (*amqp.Connection, error) {
+	for {
+		rc.mu.Lock()
+		state, conn, connected := rc.state, rc.conn, rc.connected
+		rc.mu.Unlock()
+
+		switch state {
+		case StateConnected:
+			return conn, nil
+		case StateClosed:
+			return nil, ErrClosed
+		}
+
+		select {
+		case <-connected:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-rc.ctx.Done():
+			return nil, ErrClosed
+		}
+	}
+}
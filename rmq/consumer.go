@@ -0,0 +1,296 @@
+package rmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Kana-v1-exchange/enviroment/retry"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrAlreadyAcknowledged is returned when Ack, Nack or Reject is called on a message for the second time.
+var ErrAlreadyAcknowledged = errors.New("message is already acknowledged")
+
+var consumerSeq uint64
+
+type ConsumeOptions struct {
+	Workers  int // messages handled at once; zero means 1
+	Prefetch int // unacknowledged messages the broker sends ahead; zero means Workers
+}
+
+// Handler processes a message and acknowledges it with Ack, Nack or Reject. A message left
+// unacknowledged is acked when the handler returns nil. When the handler returns an error the message
+// is retried after a delay, and after RMQSettings.MaxAttempts attempts it goes to the dead-letter queue.
+type Handler func(ctx context.Context, msg *Message) error
+
+// Message is a delivery that must be acknowledged exactly once. Acknowledging fails
+// if the connection was lost meanwhile; the broker then redelivers the message.
+type Message struct {
+	Body        []byte
+	Headers     amqp.Table
+	Redelivered bool
+	RetryCount  int // failed attempts so far
+
+	delivery     amqp.Delivery
+	acknowledged int32
+}
+
+func (m *Message) Ack() error {
+	return m.acknowledge("ack", func() error { return m.delivery.Ack(false) })
+}
+
+// Nack tells the broker the message was not processed. It is delivered again if requeue is set
+// and dropped otherwise.
+func (m *Message) Nack(requeue bool) error {
+	return m.acknowledge("nack", func() error { return m.delivery.Nack(false, requeue) })
+}
+
+// Reject drops the message for good.
+func (m *Message) Reject() error {
+	return m.acknowledge("reject", func() error { return m.delivery.Reject(false) })
+}
+
+func (m *Message) acknowledge(action string, fn func() error) error {
+	if !atomic.CompareAndSwapInt32(&m.acknowledged, 0, 1) {
+		return fmt.Errorf("cannot %v message %v; err: %w", action, m.delivery.DeliveryTag, ErrAlreadyAcknowledged)
+	}
+
+	err := fn()
+	if err != nil {
+		return fmt.Errorf("cannot %v message %v; err: %w", action, m.delivery.DeliveryTag, classify(err))
+	}
+
+	return nil
+}
+
+func (m *Message) isAcknowledged() bool {
+	return atomic.LoadInt32(&m.acknowledged) == 1
+}
+
+// subscription tells a consumer which queue it reads and what happens to messages its handler fails.
+type subscription struct {
+	name    string                                 // used in consumer tags and errors
+	declare func(ch *amqp.Channel) (string, error) // declares the queue if needed and returns its name
+	failed  func(ctx context.Context, msg *Message, cause error) error
+}
+
+func (rc *rmqClient) Consume(ctx context.Context, opts ConsumeOptions, handler Handler) error {
+	return rc.subscribe(ctx, opts, handler, &subscription{
+		name: queueName,
+		declare: func(ch *amqp.Channel) (string, error) {
+			return queueName, nil
+		},
+		failed: func(ctx context.Context, msg *Message, cause error) error {
+			return rc.retryLater(ctx, queueName, msg, cause)
+		},
+	})
+}
+
+func (rc *rmqClient) subscribe(ctx context.Context, opts ConsumeOptions, handler Handler, sub *subscription) error {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+
+	if opts.Prefetch <= 0 {
+		opts.Prefetch = opts.Workers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-rc.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// handlers that run when ctx is done still have to finish and acknowledge their messages
+	handlerCtx, cancelHandlers := context.WithCancel(detachedContext{ctx})
+	defer cancelHandlers()
+
+	jobs := make(chan amqp.Delivery)
+	workers := sync.WaitGroup{}
+
+	for i := 0; i < opts.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+
+			for delivery := range jobs {
+				rc.handle(handlerCtx, handler, sub, delivery)
+			}
+		}()
+	}
+
+	ch, err := rc.dispatch(ctx, opts, sub, jobs)
+
+	close(jobs)
+	workers.Wait()
+
+	if ch != nil {
+		ch.Close()
+	}
+
+	if rc.ctx.Err() != nil {
+		return fmt.Errorf("consuming %v stopped; err: %w", sub.name, ErrClosed)
+	}
+
+	return err
+}
+
+// detachedContext has the values of its parent but is never done.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// dispatch passes deliveries to the workers until ctx is done, opening a new channel after reconnects.
+// It returns the channel the workers may still acknowledge messages on.
+func (rc *rmqClient) dispatch(ctx context.Context, opts ConsumeOptions, sub *subscription, jobs chan<- amqp.Delivery) (*amqp.Channel, error) {
+	consumerTag := fmt.Sprintf("%v-consumer-%v", sub.name, atomic.AddUint64(&consumerSeq, 1))
+
+	for {
+		ch, deliveries, err := rc.openConsumer(ctx, sub, consumerTag, opts.Prefetch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		stopped := false
+
+		for !stopped {
+			select {
+			case <-ctx.Done():
+				stopped = true
+			case delivery, ok := <-deliveries:
+				if !ok {
+					stopped = true
+					break
+				}
+
+				select {
+				case jobs <- delivery:
+				case <-ctx.Done():
+					delivery.Nack(false, true)
+					stopped = true
+				}
+			}
+		}
+
+		if ctx.Err() != nil {
+			// messages the workers still handle need the channel open to be acknowledged,
+			// so only stop the broker sending new ones
+			ch.Cancel(consumerTag, false)
+			return ch, nil
+		}
+
+		ch.Close()
+	}
+}
+
+// openConsumer opens a channel of its own for the consumer so that its prefetch does not
+// affect other consumers, and declares the queue again, as exclusive queues die with the connection.
+// It waits for the connection and retries until ctx is done.
+func (rc *rmqClient) openConsumer(ctx context.Context, sub *subscription, consumerTag string, prefetch int) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	var ch *amqp.Channel
+	var deliveries <-chan amqp.Delivery
+
+	opts := rc.opts
+	opts.MaxElapsedTime = 0
+
+	err := retry.Do(ctx, opts, "starting the rmq consumer", func(ctx context.Context) error {
+		conn, err := rc.waitConnected(ctx)
+		if err != nil {
+			return err
+		}
+
+		ch, err = conn.Channel()
+		if err != nil {
+			return fmt.Errorf("rmq connection cannot create a channel; err: %w", classify(err))
+		}
+
+		err = ch.Qos(prefetch, 0, false)
+		if err != nil {
+			ch.Close()
+			return fmt.Errorf("cannot set prefetch of the rmq channel to %v; err: %w", prefetch, classify(err))
+		}
+
+		queue, err := sub.declare(ch)
+		if err != nil {
+			ch.Close()
+			return err
+		}
+
+		deliveries, err = ch.Consume(
+			queue,
+			consumerTag,
+			false,
+			false,
+			false,
+			false,
+			nil,
+		)
+
+		if err != nil {
+			ch.Close()
+			return fmt.Errorf("cannot get messages from the queue '%v'; err: %w", queue, classify(err))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ch, deliveries, nil
+}
+
+func (rc *rmqClient) handle(ctx context.Context, handler Handler, sub *subscription, delivery amqp.Delivery) {
+	msg := &Message{
+		Body:        delivery.Body,
+		Headers:     delivery.Headers,
+		Redelivered: delivery.Redelivered,
+		RetryCount:  retryCount(delivery.Headers),
+		delivery:    delivery,
+	}
+
+	err := handler(ctx, msg)
+	if msg.isAcknowledged() {
+		return
+	}
+
+	if err != nil {
+		err = sub.failed(ctx, msg, err)
+	} else {
+		err = msg.Ack()
+	}
+
+	if err != nil {
+		rc.logf("%v", err)
+	}
+}
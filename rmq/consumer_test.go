@@ -0,0 +1,28 @@
+package rmq
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDetachedContext(t *testing.T) {
+	parent, cancel := context.WithCancel(WithCorrelationID(context.Background(), "correlation"))
+	ctx, cancelDetached := context.WithCancel(detachedContext{parent})
+	defer cancelDetached()
+
+	cancel()
+
+	if ctx.Err() != nil {
+		t.Errorf("detached context is done after its parent was cancelled; err: %v", ctx.Err())
+	}
+
+	if got := CorrelationID(ctx); got != "correlation" {
+		t.Errorf("CorrelationID() = %q, want the one of the parent", got)
+	}
+
+	cancelDetached()
+
+	if ctx.Err() == nil {
+		t.Error("detached context is not done after it was cancelled")
+	}
+}
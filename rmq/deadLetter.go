@@ -0,0 +1,343 @@
+package rmq
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// A message whose handler fails waits in a retry queue and comes back to the queue it was consumed from
+// once its TTL expires. After MaxAttempts failed attempts it goes to the dead-letter queue
+// instead, where it stays until it is replayed. Copies are published with the name of the queue
+// they came from as the routing key, which is how they find their way back.
+const (
+	deadLetterExchange = queueName + ".dlx"
+	deadLetterQueue    = queueName + ".dlq"
+	retryQueuePrefix   = queueName + ".delay."
+
+	retryCountHeader = "x-retry-count"
+	lastErrorHeader  = "x-last-error"
+
+	defaultMaxAttempts = 5
+)
+
+var defaultRetryDelays = []time.Duration{time.Second, 10 * time.Second, time.Minute}
+
+// DeadLetter is a message that failed MaxAttempts times.
+type DeadLetter struct {
+	Body       []byte
+	Headers    amqp.Table
+	RetryCount int
+	LastError  string
+}
+
+// declareFailureTopology declares the dead-letter exchange and queue and a retry queue for every delay,
+// each behind a fanout exchange of the same name. Retry queues have no consumers: the broker dead-letters
+// expired messages through the default exchange, so they come back to the queue their routing key names.
+func (rmqS *RMQSettings) declareFailureTopology(ch *amqp.Channel) error {
+	err := ch.ExchangeDeclare(deadLetterExchange, amqp.ExchangeDirect, true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("cannot create the '%v' exchange; err: %w", deadLetterExchange, classify(err))
+	}
+
+	_, err = ch.QueueDeclare(deadLetterQueue, true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("cannot create the '%v' queue; err: %w", deadLetterQueue, classify(err))
+	}
+
+	err = bindDeadLetters(ch, queueName)
+	if err != nil {
+		return err
+	}
+
+	for _, delay := range rmqS.retryDelays() {
+		name := retryQueueName(delay)
+
+		err = ch.ExchangeDeclare(name, amqp.ExchangeFanout, true, false, false, false, nil)
+		if err != nil {
+			return fmt.Errorf("cannot create the '%v' exchange; err: %w", name, classify(err))
+		}
+
+		_, err = ch.QueueDeclare(name, true, false, false, false, amqp.Table{"x-dead-letter-exchange": ""})
+		if err != nil {
+			return fmt.Errorf("cannot create the '%v' queue; err: %w", name, classify(err))
+		}
+
+		err = ch.QueueBind(name, "", name, false, nil)
+		if err != nil {
+			return fmt.Errorf("cannot bind the '%v' queue; err: %w", name, classify(err))
+		}
+	}
+
+	return nil
+}
+
+// bindDeadLetters routes the dead letters of the queue to the dead-letter queue.
+func bindDeadLetters(ch *amqp.Channel, queue string) error {
+	err := ch.QueueBind(deadLetterQueue, queue, deadLetterExchange, false, nil)
+	if err != nil {
+		return fmt.Errorf("cannot bind the '%v' queue to the dead letters of '%v'; err: %w", deadLetterQueue, queue, classify(err))
+	}
+
+	return nil
+}
+
+// DeadLetters returns up to limit messages of the dead-letter queue without removing them.
+func (rc *rmqClient) DeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error) {
+	ch, err := rc.openChannel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// deliveries that were not acknowledged go back to the queue in their order when the channel is closed
+	defer ch.Close()
+
+	res := make([]*DeadLetter, 0)
+
+	for len(res) < limit {
+		delivery, ok, err := ch.Get(deadLetterQueue, false)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get messages from the queue '%v'; err: %w", deadLetterQueue, classify(err))
+		}
+
+		if !ok {
+			break
+		}
+
+		res = append(res, newDeadLetter(delivery))
+	}
+
+	return res, nil
+}
+
+// ReplayDeadLetters moves up to limit messages from the dead-letter queue back to the queues they failed in
+// with their retry count reset, and returns how many were moved. A message leaves the dead-letter queue
+// only after the broker confirmed that a queue took its copy; a message whose queue is gone stays
+// in the dead-letter queue and stops the replay with ErrUnroutable.
+func (rc *rmqClient) ReplayDeadLetters(ctx context.Context, limit int) (int, error) {
+	ch, returns, err := rc.openConfirmChannel(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	replayed := 0
+
+	for replayed < limit {
+		delivery, ok, err := ch.Get(deadLetterQueue, false)
+		if err != nil {
+			return replayed, fmt.Errorf("cannot get messages from the queue '%v'; err: %w", deadLetterQueue, classify(err))
+		}
+
+		if !ok {
+			break
+		}
+
+		publishing := publishingOf(delivery)
+		delete(publishing.Headers, retryCountHeader)
+		delete(publishing.Headers, lastErrorHeader)
+
+		queue := delivery.RoutingKey
+		if queue == "" {
+			queue = queueName
+		}
+
+		err = publishConfirmed(ch, returns, "", queue, publishing)
+		if err != nil {
+			delivery.Nack(false, true)
+			return replayed, fmt.Errorf("cannot replay a dead letter to the queue '%v'; err: %w", queue, err)
+		}
+
+		err = delivery.Ack(false)
+		if err != nil {
+			return replayed, fmt.Errorf("cannot remove a replayed dead letter; err: %w", classify(err))
+		}
+
+		replayed++
+
+		if ctx.Err() != nil {
+			return replayed, ctx.Err()
+		}
+	}
+
+	return replayed, nil
+}
+
+// retryLater sends a copy of the failed message to the retry queue of its attempt or, once it
+// used up its attempts, to the dead-letter queue. The message is acked only after the broker confirmed
+// the copy; if the copy cannot be published it is requeued, and it is counted as one more attempt
+// only when it fails again.
+func (rc *rmqClient) retryLater(ctx context.Context, queue string, msg *Message, cause error) error {
+	publishing := publishingOf(msg.delivery)
+	publishing.Headers[lastErrorHeader] = cause.Error()
+
+	exchange, destination := deadLetterExchange, deadLetterQueue
+
+	if msg.RetryCount+1 < rc.settings.maxAttempts() {
+		delay := rc.settings.retryDelay(msg.RetryCount)
+
+		publishing.Headers[retryCountHeader] = int32(msg.RetryCount + 1)
+		publishing.Expiration = strconv.FormatInt(delay.Milliseconds(), 10)
+		exchange, destination = retryQueueName(delay), retryQueueName(delay)
+	}
+
+	err := rc.publishCopy(ctx, exchange, queue, publishing)
+	if err != nil {
+		nackErr := msg.Nack(true)
+		if nackErr != nil {
+			return fmt.Errorf("cannot move a failed message to the queue '%v'; err: %w; %v", destination, err, nackErr)
+		}
+
+		return fmt.Errorf("cannot move a failed message to the queue '%v'; err: %w", destination, err)
+	}
+
+	return msg.Ack()
+}
+
+// publishCopy publishes on a channel of its own and waits until the broker confirms that a queue took
+// the message. It fails with ErrNacked or ErrUnroutable otherwise, so the caller can keep the original.
+func (rc *rmqClient) publishCopy(ctx context.Context, exchange, key string, publishing amqp.Publishing) error {
+	ch, returns, err := rc.openConfirmChannel(ctx)
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	return publishConfirmed(ch, returns, exchange, key, publishing)
+}
+
+// openConfirmChannel opens a short-lived channel in confirm mode along with the returns of its publishings.
+func (rc *rmqClient) openConfirmChannel(ctx context.Context) (*amqp.Channel, chan amqp.Return, error) {
+	ch, err := rc.openChannel(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = ch.Confirm(false)
+	if err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("cannot put the rmq channel in confirm mode; err: %w", classify(err))
+	}
+
+	return ch, ch.NotifyReturn(make(chan amqp.Return, 1)), nil
+}
+
+// publishConfirmed publishes a mandatory message on a channel of openConfirmChannel and waits for its confirm.
+// The broker acks messages it returns as unroutable too, so a return is checked as well; it comes before the ack.
+func publishConfirmed(ch *amqp.Channel, returns chan amqp.Return, exchange, key string, publishing amqp.Publishing) error {
+	confirmation, err := ch.PublishWithDeferredConfirm(exchange, key, true, false, publishing)
+	if err != nil {
+		return classify(err)
+	}
+
+	if !confirmation.Wait() {
+		return ErrNacked
+	}
+
+	select {
+	case ret := <-returns:
+		return fmt.Errorf("%w: %v %v", ErrUnroutable, ret.ReplyCode, ret.ReplyText)
+	default:
+		return nil
+	}
+}
+
+// openChannel opens a short-lived channel for management operations.
+func (rc *rmqClient) openChannel(ctx context.Context) (*amqp.Channel, error) {
+	conn, err := rc.waitConnected(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open an rmq channel; err: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("rmq connection cannot create a channel; err: %w", classify(err))
+	}
+
+	return ch, nil
+}
+
+func (rmqS *RMQSettings) maxAttempts() int {
+	if rmqS.MaxAttempts > 0 {
+		return rmqS.MaxAttempts
+	}
+
+	return defaultMaxAttempts
+}
+
+func (rmqS *RMQSettings) retryDelays() []time.Duration {
+	if len(rmqS.RetryDelays) > 0 {
+		return rmqS.RetryDelays
+	}
+
+	return defaultRetryDelays
+}
+
+// retryDelay returns the wait before the retry that follows the failed attempt; the last delay repeats.
+func (rmqS *RMQSettings) retryDelay(retryCount int) time.Duration {
+	delays := rmqS.retryDelays()
+	if retryCount >= len(delays) {
+		return delays[len(delays)-1]
+	}
+
+	return delays[retryCount]
+}
+
+func retryQueueName(delay time.Duration) string {
+	return retryQueuePrefix + strconv.FormatInt(delay.Milliseconds(), 10)
+}
+
+func newDeadLetter(delivery amqp.Delivery) *DeadLetter {
+	lastError, _ := delivery.Headers[lastErrorHeader].(string)
+
+	return &DeadLetter{
+		Body:       delivery.Body,
+		Headers:    delivery.Headers,
+		RetryCount: retryCount(delivery.Headers),
+		LastError:  lastError,
+	}
+}
+
+// publishingOf copies a delivery into a publishing with its own headers table.
+func publishingOf(delivery amqp.Delivery) amqp.Publishing {
+	headers := make(amqp.Table, len(delivery.Headers)+2)
+	for k, v := range delivery.Headers {
+		headers[k] = v
+	}
+
+	return amqp.Publishing{
+		Headers:         headers,
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		DeliveryMode:    delivery.DeliveryMode,
+		Priority:        delivery.Priority,
+		CorrelationId:   delivery.CorrelationId,
+		ReplyTo:         delivery.ReplyTo,
+		MessageId:       delivery.MessageId,
+		Timestamp:       delivery.Timestamp,
+		Type:            delivery.Type,
+		AppId:           delivery.AppId,
+		Body:            delivery.Body,
+	}
+}
+
+// retryCount reads the retry count header, which comes back from the broker as any integer type.
+func retryCount(headers amqp.Table) int {
+	switch v := headers[retryCountHeader].(type) {
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+
+	return 0
+}
@@ -0,0 +1,50 @@
+package rmq
+
+import (
+	"errors"
+
+	"github.com/Kana-v1-exchange/enviroment/internal/errkind"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Errors returned by the package. Check them with errors.Is; the client error stays in the chain.
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrConnectionLost = errors.New("connection to the rmq is lost")
+	ErrClosed         = errors.New("rmq client is closed")
+	ErrNacked         = errors.New("message was nacked by the broker")
+	ErrUnroutable     = errors.New("message was returned by the broker as unroutable")
+)
+
+// isAccessRefused reports whether the broker rejected the credentials or the virtual host.
+func isAccessRefused(err error) bool {
+	var amqpErr *amqp.Error
+	return (errors.As(err, &amqpErr) && amqpErr.Code == amqp.AccessRefused) || errors.Is(err, amqp.ErrCredentials)
+}
+
+// classify wraps a client error so that it matches the package error it stands for.
+// Errors that do not stand for any are returned as is.
+func classify(err error) error {
+	return errkind.Classify(err, kindOf)
+}
+
+// kindOf returns the package error a client error stands for, or nil.
+func kindOf(err error) error {
+	var amqpErr *amqp.Error
+	if errors.As(err, &amqpErr) {
+		switch amqpErr.Code {
+		case amqp.NotFound:
+			return ErrNotFound
+		case amqp.ChannelError, amqp.ConnectionForced, amqp.FrameError:
+			return ErrConnectionLost
+		}
+
+		return nil
+	}
+
+	if errkind.IsNetwork(err) {
+		return ErrConnectionLost
+	}
+
+	return nil
+}
@@ -1,9 +1,21 @@
 package rmq
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/Kana-v1-exchange/enviroment/retry"
 	amqp "github.com/rabbitmq/amqp091-go"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	queueName = "exchanges"
+
+	defaultPublishBufferSize = 1000
 )
 
 type RMQSettings struct {
@@ -11,31 +23,134 @@ type RMQSettings struct {
 	Password string
 	Host     string
 	Port     string
+
+	PublishBufferSize int  // messages kept while the broker is unreachable; zero means 1000
+	PublisherConfirms bool // puts the channel in confirm mode; required by WriteConfirmed
+
+	MaxAttempts int             // times Consume handles a message before dead-lettering it; zero means 5
+	RetryDelays []time.Duration // waits before the 1st, 2nd... retry, the last one repeats; empty means 1s, 10s, 1m
+
+	ContentType string // of envelopes sent by Publish: events.ContentTypeProtobuf (default) or events.ContentTypeJSON
 }
 
 type RmqHandler interface {
+	// Write publishes msg to the 'exchanges' queue. While the connection is being restored
+	// the message is buffered and published after reconnecting.
 	Write(msg string) error
+	WriteConfirmed(ctx context.Context, msg string) error
+
+	// Read returns deliveries of the 'exchanges' queue. They are acknowledged automatically,
+	// so a message is lost if the reader fails to process it; use Consume for manual acknowledgement.
+	// The channel survives reconnects and is closed only by Close.
 	Read() (<-chan amqp.Delivery, error)
+
+	// Consume hands messages of the 'exchanges' queue to handler, which must acknowledge them.
+	// It survives reconnects and returns once ctx is done and the handlers being run have returned.
+	// Handlers get the values of ctx but not its cancellation, so they can finish the message they handle.
+	Consume(ctx context.Context, opts ConsumeOptions, handler Handler) error
+
+	// Publish and Subscribe send and receive typed events wrapped in envelopes.
+	Publish(ctx context.Context, event proto.Message) error
+	PublishConfirmed(ctx context.Context, event *EncodedEvent) error
+	Subscribe(ctx context.Context, queue string, opts ConsumeOptions, handlers EventHandlers) error
+	SubscribeTopics(ctx context.Context, opts ConsumeOptions, patterns []string, handlers EventHandlers) error
+
+	DeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error)
+	ReplayDeadLetters(ctx context.Context, limit int) (int, error)
+
+	State() ConnectionState
+	NotifyState(receiver chan ConnectionState) chan ConnectionState
+	Close() error
 }
 
 type rmqClient struct {
-	ch *amqp.Channel
+	settings *RMQSettings
+	opts     retry.Options
+
+	ctx    context.Context // cancelled by Close
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	conn      *amqp.Connection
+	ch        *amqp.Channel
+	confirms  *confirmTracker // tracks the current channel
+	state     ConnectionState
+	connected chan struct{} // closed while the state is StateConnected
+	listeners []chan ConnectionState
+	pending   []*outgoing
+	consumers []chan amqp.Delivery
+
+	forwarders sync.WaitGroup
+	supervisor sync.WaitGroup
 }
 
-func (rmqS *RMQSettings) Connect() RmqHandler {
-	conn, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%s", rmqS.User, rmqS.Password, rmqS.Host, rmqS.Port))
+// MustConnect is Connect with the default retry options that panics when the broker stays unreachable.
+func (rmqS *RMQSettings) MustConnect() RmqHandler {
+	rh, err := rmqS.Connect(context.Background(), retry.DefaultOptions())
+	if err != nil {
+		panic(err)
+	}
+
+	return rh
+}
+
+// Connect dials the broker and declares the 'exchanges' queue, retrying with backoff as opts tell.
+// Once connected, the client restores the connection by itself whenever it is lost;
+// those attempts use the same opts but go on until Close.
+func (rmqS *RMQSettings) Connect(ctx context.Context, opts retry.Options) (RmqHandler, error) {
+	var conn *amqp.Connection
+	var ch *amqp.Channel
+
+	err := retry.Do(ctx, opts, "connecting to rmq", func(ctx context.Context) error {
+		var err error
+		conn, ch, err = rmqS.open()
+
+		// wrong credentials or virtual host do not get better with retrying; once connected,
+		// the client keeps reconnecting anyway, as the broker may just be restarting
+		if isAccessRefused(err) {
+			return retry.Permanent(err)
+		}
 
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &rmqClient{
+		settings:  rmqS,
+		opts:      opts,
+		state:     StateConnected,
+		connected: make(chan struct{}),
+	}
+
+	close(rc.connected)
+
+	rc.useChannel(conn, ch)
+
+	rc.ctx, rc.cancel = context.WithCancel(context.Background())
+
+	rc.supervisor.Add(1)
+	go rc.supervise()
+
+	return rc, nil
+}
+
+func (rmqS *RMQSettings) open() (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%s", rmqS.User, rmqS.Password, rmqS.Host, rmqS.Port))
 	if err != nil {
-		panic(fmt.Sprintf("cannot connect to the rmq; err: %v", err))
+		return nil, nil, fmt.Errorf("cannot connect to the rmq; err: %w", classify(err))
 	}
 
 	ch, err := conn.Channel()
 	if err != nil {
-		panic(fmt.Sprintf("rmq connection cannot create a channel; err: %v", err))
+		conn.Close()
+		return nil, nil, fmt.Errorf("rmq connection cannot create a channel; err: %w", classify(err))
 	}
 
 	_, err = ch.QueueDeclare(
-		"exchanges",
+		queueName,
 		true,
 		false,
 		false,
@@ -44,36 +159,138 @@ func (rmqS *RMQSettings) Connect() RmqHandler {
 	)
 
 	if err != nil {
-		panic(fmt.Errorf("cannot create the 'exchange' queue; err: %v", err))
+		conn.Close()
+		return nil, nil, fmt.Errorf("cannot create the '%v' queue; err: %w", queueName, classify(err))
+	}
+
+	err = ch.ExchangeDeclare(eventsExchange, amqp.ExchangeTopic, true, false, false, false, nil)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("cannot create the '%v' exchange; err: %w", eventsExchange, classify(err))
 	}
 
-	return &rmqClient{
-		ch: ch,
+	err = rmqS.declareFailureTopology(ch)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
 	}
+
+	if rmqS.PublisherConfirms {
+		err = ch.Confirm(false)
+		if err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("cannot put the rmq channel in confirm mode; err: %w", classify(err))
+		}
+	}
+
+	return conn, ch, nil
 }
 
 func (rc *rmqClient) Write(msg string) error {
-	err := rc.ch.Publish(
-		"",
-		"exchanges",
-		false,
-		false,
-		amqp.Publishing{
+	err := rc.write(&outgoing{
+		key: queueName,
+		publishing: amqp.Publishing{
 			ContentType: "text/plain",
 			Body:        []byte(msg),
 		},
-	)
+	})
 
 	if err != nil {
-		return fmt.Errorf("cannot publish message '%s'; err: %v", msg, err)
+		return fmt.Errorf("cannot publish message '%s'; err: %w", msg, err)
 	}
 
 	return nil
 }
 
 func (rc *rmqClient) Read() (<-chan amqp.Delivery, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.state == StateClosed {
+		return nil, fmt.Errorf("cannot get messages from the queue '%v'; err: %w", queueName, ErrClosed)
+	}
+
+	out := make(chan amqp.Delivery)
+
+	// while reconnecting, the consumer is started by the supervisor
+	if rc.state == StateConnected {
+		err := rc.consume(out)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rc.consumers = append(rc.consumers, out)
+
+	return out, nil
+}
+
+// outgoing is a message with its destination.
+type outgoing struct {
+	exchange   string // empty for the default exchange, where key is the queue name
+	key        string
+	publishing amqp.Publishing
+	optional   bool // the broker may drop the message if no queue takes it
+}
+
+// write publishes the message or buffers it while the connection is being restored.
+func (rc *rmqClient) write(msg *outgoing) error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	switch rc.state {
+	case StateClosed:
+		return ErrClosed
+	case StateConnecting:
+		return rc.buffer(msg)
+	}
+
+	err := rc.publish(msg)
+	if err != nil {
+		err = classify(err)
+		if errors.Is(err, ErrConnectionLost) {
+			return rc.buffer(msg)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// publish must be called with mu held. Publishings that are not optional are mandatory,
+// so the broker returns the ones no queue takes; the confirm tracker reports them.
+func (rc *rmqClient) publish(msg *outgoing) error {
+	return rc.ch.Publish(
+		msg.exchange,
+		msg.key,
+		!msg.optional,
+		false,
+		msg.publishing,
+	)
+}
+
+// useChannel must be called with mu held or before the client is shared.
+func (rc *rmqClient) useChannel(conn *amqp.Connection, ch *amqp.Channel) {
+	rc.conn, rc.ch = conn, ch
+	rc.confirms = newConfirmTracker(ch, rc.settings.PublisherConfirms, rc.opts.Logger)
+}
+
+// buffer must be called with mu held.
+func (rc *rmqClient) buffer(msg *outgoing) error {
+	if len(rc.pending) >= rc.publishBufferSize() {
+		return fmt.Errorf("cannot buffer the message: %v messages are already waiting for the connection; err: %w", len(rc.pending), ErrConnectionLost)
+	}
+
+	rc.pending = append(rc.pending, msg)
+	return nil
+}
+
+// consume must be called with mu held. It forwards deliveries of the current channel to out
+// until the channel is closed.
+func (rc *rmqClient) consume(out chan amqp.Delivery) error {
 	msgs, err := rc.ch.Consume(
-		"exchanges",
+		queueName,
 		"",
 		true,
 		false,
@@ -83,8 +300,35 @@ func (rc *rmqClient) Read() (<-chan amqp.Delivery, error) {
 	)
 
 	if err != nil {
-		return nil, fmt.Errorf("cannot get messages from the queue 'exchanges'; err: %v", err)
+		return fmt.Errorf("cannot get messages from the queue '%v'; err: %w", queueName, classify(err))
 	}
 
-	return msgs, nil
+	rc.forwarders.Add(1)
+	go func() {
+		defer rc.forwarders.Done()
+
+		for msg := range msgs {
+			select {
+			case out <- msg:
+			case <-rc.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (rc *rmqClient) publishBufferSize() int {
+	if rc.settings.PublishBufferSize > 0 {
+		return rc.settings.PublishBufferSize
+	}
+
+	return defaultPublishBufferSize
+}
+
+func (rc *rmqClient) logf(format string, v ...interface{}) {
+	if rc.opts.Logger != nil {
+		rc.opts.Logger.Printf(format, v...)
+	}
 }